@@ -0,0 +1,322 @@
+//go:build darwin || linux
+
+package posix
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"unsafe"
+)
+
+// ErrNoArena is returned by OpenArena for an object that does not hold an arena.
+var ErrNoArena = errors.New("posix: not an arena")
+
+const (
+	arenaMagic    = 0x414e455241584953 // "SIXARENA"
+	arenaVersion  = 1
+	arenaClasses  = 48 // size classes 1<<arenaMinClass … 1<<(arenaClasses-1)
+	arenaMinClass = 5  // smallest block: 16-byte header + 16 bytes
+	arenaAlign    = 16 // minimum alignment of an allocation
+	arenaOffMask  = 1<<48 - 1
+	arenaLive     = 0x4556494c // "LIVE", blockHeader.State of an allocated block
+)
+
+// arenaHeader is the layout of the start of an arena object. Free holds one
+// Treiber stack per size class; each head packs a block offset in the low 48
+// bits and a generation tag, bumped on every change, in the high 16.
+type arenaHeader struct {
+	Magic   atomic.Uint64
+	Version uint32
+	_       uint32
+	Size    atomic.Uint64 // current size of the object
+	Top     atomic.Uint64 // bump pointer: offset of the first never-used byte
+	Grow    atomic.Uint32 // pid of the process extending the object, 0 if none
+	_       uint32
+	Free    [arenaClasses]atomic.Uint64
+}
+
+// arenaStart is the offset of the first block, after the header.
+var arenaStart = (uint64(unsafe.Sizeof(arenaHeader{})) + 63) &^ 63
+
+// blockHeader sits immediately before every allocation. While the block is on
+// a free list the word at its start+8 links to the next free block; that may
+// overlap Start, which is only needed while the block is allocated.
+type blockHeader struct {
+	Class uint32
+	State atomic.Uint32
+	Start uint64
+}
+
+// Arena is a heap kept inside a shared object: allocations are offsets into the
+// object, and the free lists live in the object too, so any number of
+// processes that open the same object allocate from and free to one heap.
+// Blocks come in power-of-two size classes, are recycled through lock-free
+// per-class free lists, and are carved from the end of the heap when a list is
+// empty. When that runs out the object is extended with Ftruncate and mapped
+// again.
+//
+// Offsets are the stable names for allocations; Bytes returns the current
+// mapping to reach them through. Earlier mappings stay valid until Close, so a
+// slice taken before the arena grew still works — it just does not reach the
+// new space. An Arena is safe for concurrent use, within and across processes.
+//
+// Growth needs an object that can be extended; on macOS a POSIX shared-memory
+// object cannot be resized once set, so use a regular file there. Extending is
+// serialized across processes by a lock in the header that records its
+// holder's pid. If the holder dies, the others take the lock over once the
+// pid is gone; until its parent reaps it, a dead process still counts as alive,
+// and if its pid is reused the others wait for the new process instead.
+type Arena struct {
+	mu   sync.Mutex
+	fd   int
+	data []byte   // current mapping
+	old  [][]byte // earlier, smaller mappings, released by Close
+}
+
+// NewArena makes the object behind fd an empty arena of size bytes, extending it
+// with Ftruncate, and returns a handle on it. Any previous contents are lost.
+// The arena does not own fd; the caller closes it.
+func NewArena(fd int, size int) (*Arena, error) {
+	if size < int(arenaStart) {
+		return nil, EINVAL
+	}
+	if err := Ftruncate(fd, size); err != nil {
+		return nil, err
+	}
+	data, _, err := Mmap(nil, size, PROT_RDWR, MAP_SHARED, fd, 0)
+	if err != nil {
+		return nil, err
+	}
+	h := (*arenaHeader)(unsafe.Pointer(&data[0]))
+	h.Version = arenaVersion
+	h.Size.Store(uint64(size))
+	h.Top.Store(arenaStart)
+	h.Grow.Store(0)
+	for i := range h.Free {
+		h.Free[i].Store(0)
+	}
+	h.Magic.Store(arenaMagic)
+	return &Arena{fd: fd, data: data}, nil
+}
+
+// OpenArena returns a handle on the arena NewArena made in the object behind
+// fd, possibly in another process. It fails with ErrNoArena if there is none.
+// The arena does not own fd; the caller closes it.
+func OpenArena(fd int) (*Arena, error) {
+	var st Stat_t
+	if err := Fstat(fd, &st); err != nil {
+		return nil, err
+	}
+	if uint64(st.Size) < arenaStart {
+		return nil, ErrNoArena
+	}
+	data, _, err := Mmap(nil, int(st.Size), PROT_RDWR, MAP_SHARED, fd, 0)
+	if err != nil {
+		return nil, err
+	}
+	h := (*arenaHeader)(unsafe.Pointer(&data[0]))
+	if h.Magic.Load() != arenaMagic {
+		_ = Munmap(data)
+		return nil, ErrNoArena
+	}
+	if h.Version != arenaVersion {
+		_ = Munmap(data)
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrNoArena, h.Version)
+	}
+	return &Arena{fd: fd, data: data}, nil
+}
+
+// Bytes returns the arena's current mapping, offset 0 being the start of the
+// object. It reaches every allocation made so far, in any process. It is nil
+// once the arena is closed.
+func (a *Arena) Bytes() []byte {
+	data, err := a.mapped(0)
+	if err != nil {
+		return nil
+	}
+	data, _ = a.mapped((*arenaHeader)(unsafe.Pointer(&data[0])).Size.Load())
+	return data
+}
+
+// Alloc allocates size bytes aligned to align (a power of two; at least 16 is
+// used) and returns the offset of the first. It fails with ENOMEM if the
+// request is too large, and with whatever Ftruncate or Mmap returns if the
+// arena cannot grow.
+func (a *Arena) Alloc(size, align uint64) (uint64, error) {
+	if size == 0 || align&(align-1) != 0 {
+		return 0, EINVAL
+	}
+	align = max(align, arenaAlign)
+	need := size + align // room for the header and the worst-case padding
+	if need < size {
+		return 0, ENOMEM
+	}
+	class := max(bits.Len64(need-1), arenaMinClass)
+	if class >= arenaClasses {
+		return 0, ENOMEM
+	}
+	var end uint64
+	for {
+		data, err := a.mapped(end)
+		if err != nil {
+			return 0, err
+		}
+		h := (*arenaHeader)(unsafe.Pointer(&data[0]))
+		start, more, err := a.take(data, h, class)
+		if err != nil {
+			return 0, err
+		}
+		if more != 0 {
+			end = more
+			continue
+		}
+		off := (start + arenaAlign + align - 1) &^ (align - 1)
+		b := (*blockHeader)(unsafe.Pointer(&data[off-arenaAlign]))
+		b.Class = uint32(class)
+		b.Start = start
+		b.State.Store(arenaLive)
+		return off, nil
+	}
+}
+
+// Free returns the allocation at off, as returned by Alloc, to the arena. Freeing
+// an offset that is not a live allocation fails with EINVAL; that check is a
+// best effort, not a guarantee, against a corrupted heap.
+func (a *Arena) Free(off uint64) error {
+	if off < arenaStart+arenaAlign || off%arenaAlign != 0 {
+		return EINVAL
+	}
+	data, err := a.mapped(off)
+	if err != nil {
+		return err
+	}
+	b := (*blockHeader)(unsafe.Pointer(&data[off-arenaAlign]))
+	class, start := int(b.Class), b.Start
+	if class < arenaMinClass || class >= arenaClasses || start < arenaStart || start > off-arenaAlign {
+		return EINVAL
+	}
+	if !b.State.CompareAndSwap(arenaLive, 0) {
+		return EINVAL
+	}
+	h := (*arenaHeader)(unsafe.Pointer(&data[0]))
+	next := (*atomic.Uint64)(unsafe.Pointer(&data[start+8]))
+	for {
+		head := h.Free[class].Load()
+		next.Store(head & arenaOffMask)
+		if h.Free[class].CompareAndSwap(head, (head>>48+1)<<48|start) {
+			return nil
+		}
+	}
+}
+
+// Close releases the arena's mappings. Allocations are not freed: they belong
+// to the object, not to the handle.
+func (a *Arena) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.data == nil {
+		return ErrClosed
+	}
+	err := Munmap(a.data)
+	for _, b := range a.old {
+		err = errors.Join(err, Munmap(b))
+	}
+	a.data, a.old = nil, nil
+	return err
+}
+
+// take pops a block of the class from its free list, or carves one from the top
+// of the heap, and returns its offset. If the block lies beyond this process's
+// mapping it returns instead the offset the mapping must reach.
+func (a *Arena) take(data []byte, h *arenaHeader, class int) (start, more uint64, err error) {
+	size := uint64(1) << class
+	for {
+		head := h.Free[class].Load()
+		start = head & arenaOffMask
+		if start == 0 {
+			break
+		}
+		if start+size > uint64(len(data)) {
+			return 0, start + size, nil
+		}
+		next := (*atomic.Uint64)(unsafe.Pointer(&data[start+8])).Load()
+		if h.Free[class].CompareAndSwap(head, (head>>48+1)<<48|next) {
+			return start, 0, nil
+		}
+	}
+	for {
+		top := h.Top.Load()
+		end := top + size
+		if end > h.Size.Load() {
+			if err := a.grow(h, end); err != nil {
+				return 0, 0, err
+			}
+		}
+		if end > uint64(len(data)) {
+			return 0, end, nil
+		}
+		if h.Top.CompareAndSwap(top, end) {
+			return top, 0, nil
+		}
+	}
+}
+
+// grow extends the object to at least end bytes, doubling it, under the
+// cross-process lock in the header. The lock of a holder that died is broken:
+// the work is redone from the published Size, which the holder only advanced
+// once the object was extended.
+func (a *Arena) grow(h *arenaHeader, end uint64) error {
+	pid := uint32(syscall.Getpid())
+	for !h.Grow.CompareAndSwap(0, pid) {
+		if holder := h.Grow.Load(); holder != 0 && syscall.Kill(int(holder), 0) == syscall.ESRCH {
+			h.Grow.CompareAndSwap(holder, 0)
+			continue
+		}
+		runtime.Gosched()
+	}
+	defer h.Grow.Store(0)
+	size := h.Size.Load()
+	if end <= size {
+		return nil
+	}
+	for size < end {
+		size *= 2
+	}
+	if size > arenaOffMask {
+		return ENOMEM
+	}
+	if err := Ftruncate(a.fd, int(size)); err != nil {
+		return err
+	}
+	h.Size.Store(size)
+	return nil
+}
+
+// mapped returns the current mapping, first mapping the object again at its
+// current size if the mapping does not reach end.
+func (a *Arena) mapped(end uint64) ([]byte, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.data == nil {
+		return nil, ErrClosed
+	}
+	if end <= uint64(len(a.data)) {
+		return a.data, nil
+	}
+	size := (*arenaHeader)(unsafe.Pointer(&a.data[0])).Size.Load()
+	if end > size {
+		return nil, EINVAL
+	}
+	data, _, err := Mmap(nil, int(size), PROT_RDWR, MAP_SHARED, a.fd, 0)
+	if err != nil {
+		return nil, err
+	}
+	a.old = append(a.old, a.data)
+	a.data = data
+	return data, nil
+}
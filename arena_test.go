@@ -0,0 +1,231 @@
+//go:build darwin || linux
+
+package posix_test
+
+import (
+	"encoding/binary"
+	"errors"
+	"os"
+	"os/exec"
+	"sync"
+	"testing"
+	"time"
+
+	"gopkg.in/ro-ag/posix.v1"
+)
+
+// arenaFile returns the descriptor of an empty temporary file: unlike a POSIX
+// shared-memory object on macOS, it can be extended after it is first sized.
+func arenaFile(t *testing.T) int {
+	t.Helper()
+	f, err := os.CreateTemp(t.TempDir(), "arena")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = f.Close() })
+	return int(f.Fd())
+}
+
+// TestArenaAllocFree: allocations are aligned and disjoint, a freed block is
+// reused for its size class, and a double or bogus Free is refused.
+func TestArenaAllocFree(t *testing.T) {
+	a, err := posix.NewArena(arenaFile(t), posix.Getpagesize())
+	if err != nil {
+		t.Fatalf("NewArena: %v", err)
+	}
+	defer func() { _ = a.Close() }()
+
+	type alloc struct{ off, size uint64 }
+	var got []alloc
+	for _, tc := range []struct{ size, align uint64 }{
+		{1, 1}, {24, 8}, {100, 64}, {16, 16}, {300, 256}, {8, 0},
+	} {
+		off, err := a.Alloc(tc.size, tc.align)
+		if err != nil {
+			t.Fatalf("Alloc(%d, %d): %v", tc.size, tc.align, err)
+		}
+		if off%max(tc.align, 16) != 0 {
+			t.Errorf("Alloc(%d, %d) = %#x, misaligned", tc.size, tc.align, off)
+		}
+		got = append(got, alloc{off, tc.size})
+	}
+	// Fill each allocation with its index; overlapping blocks would clobber.
+	data := a.Bytes()
+	for i, g := range got {
+		for j := range g.size {
+			data[g.off+j] = byte(i + 1)
+		}
+	}
+	for i, g := range got {
+		for j := range g.size {
+			if data[g.off+j] != byte(i+1) {
+				t.Fatalf("allocation %d at %#x overwritten", i, g.off)
+			}
+		}
+	}
+
+	if err := a.Free(got[1].off); err != nil {
+		t.Fatalf("Free: %v", err)
+	}
+	if err := a.Free(got[1].off); !errors.Is(err, posix.EINVAL) {
+		t.Errorf("second Free = %v, want EINVAL", err)
+	}
+	if err := a.Free(got[2].off + 16); !errors.Is(err, posix.EINVAL) {
+		t.Errorf("Free of an interior offset = %v, want EINVAL", err)
+	}
+	if off, err := a.Alloc(20, 8); err != nil || off != got[1].off {
+		t.Errorf("Alloc after Free = %#x, %v; want the freed %#x", off, err, got[1].off)
+	}
+
+	if _, err := a.Alloc(0, 8); !errors.Is(err, posix.EINVAL) {
+		t.Errorf("Alloc(0) = %v, want EINVAL", err)
+	}
+	if _, err := a.Alloc(8, 3); !errors.Is(err, posix.EINVAL) {
+		t.Errorf("Alloc(align 3) = %v, want EINVAL", err)
+	}
+	if _, err := a.Alloc(1<<62, 8); !errors.Is(err, posix.ENOMEM) {
+		t.Errorf("Alloc(1<<62) = %v, want ENOMEM", err)
+	}
+}
+
+// TestArenaGrowShared: two handles on one object (as two processes would have)
+// share a heap, and when one grows the object the other follows; slices taken
+// before the growth stay usable.
+func TestArenaGrowShared(t *testing.T) {
+	fd := arenaFile(t)
+	pg := posix.Getpagesize()
+	a, err := posix.NewArena(fd, pg)
+	if err != nil {
+		t.Fatalf("NewArena: %v", err)
+	}
+	defer func() { _ = a.Close() }()
+	b, err := posix.OpenArena(fd)
+	if err != nil {
+		t.Fatalf("OpenArena: %v", err)
+	}
+	defer func() { _ = b.Close() }()
+
+	small, err := a.Alloc(8, 8)
+	if err != nil {
+		t.Fatalf("Alloc: %v", err)
+	}
+	before := a.Bytes()
+	before[small] = 0x11
+
+	big, err := b.Alloc(uint64(4*pg), 8)
+	if err != nil {
+		t.Fatalf("Alloc (growing): %v", err)
+	}
+	var st posix.Stat_t
+	if err := posix.Fstat(fd, &st); err != nil {
+		t.Fatal(err)
+	}
+	if int(st.Size) < 5*pg {
+		t.Errorf("object size after growth = %d, want at least %d", st.Size, 5*pg)
+	}
+	b.Bytes()[big] = 0x22
+
+	if err := a.Free(big); err != nil {
+		t.Errorf("Free through the other handle: %v", err)
+	}
+	if got := a.Bytes(); len(got) < int(st.Size) || got[big] != 0x22 || got[small] != 0x11 {
+		t.Errorf("first handle did not follow the growth: len %d", len(got))
+	}
+	if before[small] != 0x11 {
+		t.Error("slice taken before the growth no longer works")
+	}
+}
+
+// TestArenaGrowDeadHolder: a growth lock left behind by a process that died
+// holding it is taken over rather than waited on forever.
+func TestArenaGrowDeadHolder(t *testing.T) {
+	fd := arenaFile(t)
+	pg := posix.Getpagesize()
+	a, err := posix.NewArena(fd, pg)
+	if err != nil {
+		t.Fatalf("NewArena: %v", err)
+	}
+	defer func() { _ = a.Close() }()
+
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dead := exec.Command(exe, "-test.run=^$")
+	if err := dead.Run(); err != nil {
+		t.Fatalf("run child: %v", err)
+	}
+	// The header's growth lock is the word at offset 32.
+	binary.NativeEndian.PutUint32(a.Bytes()[32:], uint32(dead.Process.Pid))
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := a.Alloc(uint64(4*pg), 8)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Alloc (growing): %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("Alloc still waiting on the growth lock of a dead process")
+	}
+}
+
+// TestArenaConcurrent hammers one heap through two handles; every live
+// allocation must be owned by exactly one goroutine.
+func TestArenaConcurrent(t *testing.T) {
+	fd := arenaFile(t)
+	a, err := posix.NewArena(fd, posix.Getpagesize())
+	if err != nil {
+		t.Fatalf("NewArena: %v", err)
+	}
+	defer func() { _ = a.Close() }()
+	b, err := posix.OpenArena(fd)
+	if err != nil {
+		t.Fatalf("OpenArena: %v", err)
+	}
+	defer func() { _ = b.Close() }()
+
+	var wg sync.WaitGroup
+	var owners sync.Map
+	for g := range 8 {
+		h := a
+		if g%2 == 1 {
+			h = b
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 500 {
+				off, err := h.Alloc(uint64(16+(i%5)*40), 16)
+				if err != nil {
+					t.Errorf("Alloc: %v", err)
+					return
+				}
+				if prev, dup := owners.LoadOrStore(off, g); dup {
+					t.Errorf("offset %#x handed to goroutines %v and %d", off, prev, g)
+					return
+				}
+				owners.Delete(off)
+				if err := h.Free(off); err != nil {
+					t.Errorf("Free: %v", err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// TestOpenArenaNotArena: an object without an arena header is refused.
+func TestOpenArenaNotArena(t *testing.T) {
+	fd := arenaFile(t)
+	if err := posix.Ftruncate(fd, posix.Getpagesize()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := posix.OpenArena(fd); !errors.Is(err, posix.ErrNoArena) {
+		t.Errorf("OpenArena = %v, want ErrNoArena", err)
+	}
+}
@@ -0,0 +1,124 @@
+//go:build darwin || linux
+
+package posix
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"unsafe"
+)
+
+// BaseHeaderSize is the number of bytes PublishBase reserves at the start of the
+// object for its header. Shared data goes after it.
+const BaseHeaderSize = 64
+
+// BaseMode selects what AttachBase does when the published base address is
+// already in use in the calling process.
+type BaseMode int
+
+const (
+	// RequireBase fails with ErrAddressInUse: the caller relies on absolute
+	// pointers inside the object.
+	RequireBase BaseMode = iota
+	// AllowRelocate maps the object wherever the kernel chooses instead; the
+	// caller must then reach its data through offsets (see Off and Rel).
+	AllowRelocate
+)
+
+// ErrNoBase is returned by AttachBase for an object that carries no published
+// base address.
+var ErrNoBase = errors.New("posix: no published base address")
+
+const (
+	baseMagic   = 0x5345534142584953 // "SIXBASES"
+	baseVersion = 1
+)
+
+// baseHeader is the layout of the first BaseHeaderSize bytes of a published
+// object. Magic is written last, so a reader that sees it sees the rest.
+type baseHeader struct {
+	Magic   atomic.Uint64
+	Version uint32
+	_       uint32
+	Base    uint64
+	Length  uint64
+	_       [32]byte
+}
+
+// PublishBase maps length bytes of fd with MAP_SHARED and records the address it
+// landed at in a header at the start of the object, so that other processes can
+// map the object at the same address with AttachBase. A nil address lets the
+// kernel choose; otherwise the object is mapped at exactly address, with MmapAt.
+// prot must include PROT_WRITE, and the object must already be length bytes
+// long.
+func PublishBase(fd int, length int, address unsafe.Pointer, prot int) ([]byte, error) {
+	if length < BaseHeaderSize || prot&PROT_WRITE == 0 {
+		return nil, EINVAL
+	}
+	var data []byte
+	var err error
+	if address == nil {
+		data, _, err = Mmap(nil, length, prot, MAP_SHARED, fd, 0)
+	} else {
+		data, err = MmapAt(address, length, prot, MAP_SHARED, fd, 0)
+	}
+	if err != nil {
+		return nil, err
+	}
+	h, err := View[baseHeader](data, 0)
+	if err != nil {
+		_ = Munmap(data)
+		return nil, err
+	}
+	h.Version = baseVersion
+	h.Base = uint64(uintptr(unsafe.Pointer(&data[0])))
+	h.Length = uint64(length)
+	h.Magic.Store(baseMagic)
+	return data, nil
+}
+
+// AttachBase maps an object prepared by PublishBase at the base address recorded
+// in its header, with MAP_FIXED_NOREPLACE semantics (MmapAt). If that range is
+// already in use in this process, RequireBase fails with an error wrapping
+// ErrAddressInUse and AllowRelocate maps the object elsewhere and reports
+// relocated. An object without a header fails with ErrNoBase.
+func AttachBase(fd int, prot int, mode BaseMode) (data []byte, relocated bool, err error) {
+	base, length, err := readBase(fd)
+	if err != nil {
+		return nil, false, err
+	}
+	data, err = MmapAt(unsafe.Pointer(base), length, prot, MAP_SHARED, fd, 0)
+	if err == nil {
+		return data, false, nil
+	}
+	if !errors.Is(err, ErrAddressInUse) || mode != AllowRelocate {
+		return nil, false, fmt.Errorf("posix: published base %#x unavailable: %w", base, err)
+	}
+	data, _, err = Mmap(nil, length, prot, MAP_SHARED, fd, 0)
+	if err != nil {
+		return nil, false, err
+	}
+	return data, true, nil
+}
+
+// readBase reads the published base address and length through a short-lived
+// read-only mapping of the header.
+func readBase(fd int) (base uintptr, length int, err error) {
+	hdr, _, err := Mmap(nil, BaseHeaderSize, PROT_READ, MAP_SHARED, fd, 0)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer func() { _ = Munmap(hdr) }()
+	h, err := View[baseHeader](hdr, 0)
+	if err != nil {
+		return 0, 0, err
+	}
+	if h.Magic.Load() != baseMagic {
+		return 0, 0, ErrNoBase
+	}
+	if h.Version != baseVersion || h.Base == 0 || h.Length < BaseHeaderSize {
+		return 0, 0, fmt.Errorf("%w: unsupported header (version %d)", ErrNoBase, h.Version)
+	}
+	return uintptr(h.Base), int(h.Length), nil
+}
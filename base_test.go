@@ -0,0 +1,67 @@
+//go:build darwin || linux
+
+package posix_test
+
+import (
+	"errors"
+	"testing"
+	"unsafe"
+
+	"gopkg.in/ro-ag/posix.v1"
+)
+
+// TestPublishAttachBase exercises the base-address protocol within one
+// process, where the published range is necessarily taken: RequireBase must
+// refuse it and AllowRelocate must map the same bytes elsewhere. (The
+// same-address success path across processes is covered by TestShmRoundTrip.)
+func TestPublishAttachBase(t *testing.T) {
+	const want = 0x35000000000
+	pg := posix.Getpagesize()
+	fd, err := posix.MemfdCreate("base", posix.MFD_ALLOW_SEALING)
+	if err != nil {
+		t.Fatalf("MemfdCreate: %v", err)
+	}
+	defer func() { _ = posix.Close(fd) }()
+	if err := posix.Ftruncate(fd, 2*pg); err != nil {
+		t.Fatalf("Ftruncate: %v", err)
+	}
+
+	if _, _, err := posix.AttachBase(fd, posix.PROT_RDWR, posix.AllowRelocate); !errors.Is(err, posix.ErrNoBase) {
+		t.Errorf("AttachBase before PublishBase = %v, want ErrNoBase", err)
+	}
+
+	pub, err := posix.PublishBase(fd, 2*pg, unsafe.Pointer(uintptr(want)), posix.PROT_RDWR)
+	if err != nil {
+		t.Fatalf("PublishBase: %v", err)
+	}
+	defer func() { _ = posix.Munmap(pub) }()
+	if got := uintptr(unsafe.Pointer(&pub[0])); got != want {
+		t.Fatalf("PublishBase mapped at %#x, want %#x", got, uintptr(want))
+	}
+	pub[posix.BaseHeaderSize] = 0x99
+
+	if _, _, err := posix.AttachBase(fd, posix.PROT_RDWR, posix.RequireBase); !errors.Is(err, posix.ErrAddressInUse) {
+		t.Errorf("AttachBase(RequireBase) over a taken base = %v, want ErrAddressInUse", err)
+	}
+	moved, relocated, err := posix.AttachBase(fd, posix.PROT_RDWR, posix.AllowRelocate)
+	if err != nil {
+		t.Fatalf("AttachBase(AllowRelocate): %v", err)
+	}
+	defer func() { _ = posix.Munmap(moved) }()
+	if !relocated || &moved[0] == &pub[0] {
+		t.Errorf("AllowRelocate: relocated = %v at %p, want a relocated mapping", relocated, &moved[0])
+	}
+	if len(moved) != 2*pg || moved[posix.BaseHeaderSize] != 0x99 {
+		t.Errorf("relocated mapping: len %d byte %#x, want %d and 0x99", len(moved), moved[posix.BaseHeaderSize], 2*pg)
+	}
+}
+
+// TestPublishBaseArguments: the header needs room and a writable mapping.
+func TestPublishBaseArguments(t *testing.T) {
+	if _, err := posix.PublishBase(-1, posix.BaseHeaderSize-1, nil, posix.PROT_RDWR); !errors.Is(err, posix.EINVAL) {
+		t.Errorf("PublishBase(short) = %v, want EINVAL", err)
+	}
+	if _, err := posix.PublishBase(-1, posix.Getpagesize(), nil, posix.PROT_READ); !errors.Is(err, posix.EINVAL) {
+		t.Errorf("PublishBase(PROT_READ) = %v, want EINVAL", err)
+	}
+}
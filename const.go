@@ -12,6 +12,12 @@ const (
 	EFAULT     = syscall.EFAULT
 	EPERM      = syscall.EPERM
 	EBUSY      = syscall.EBUSY
+	EACCES     = syscall.EACCES
+	EEXIST     = syscall.EEXIST
+	ENOMEM     = syscall.ENOMEM
+	EINTR      = syscall.EINTR
+	ETIMEDOUT  = syscall.ETIMEDOUT
+	EOVERFLOW  = syscall.EOVERFLOW
 	O_RDWR     = syscall.O_RDWR     // open for reading and writing
 	O_CREAT    = syscall.O_CREAT    // create if nonexistent
 	O_EXCL     = syscall.O_EXCL     // error if already exists
@@ -20,6 +26,7 @@ const (
 	O_WRONLY   = syscall.O_WRONLY   // open for writing only
 	O_ACCMODE  = syscall.O_ACCMODE  // mask for modes O_RDONLY & O_WRONLY
 	O_CLOEXEC  = syscall.O_CLOEXEC
+	O_NONBLOCK = syscall.O_NONBLOCK // fail with EAGAIN instead of blocking
 )
 
 //goland:noinspection GoSnakeCaseUsage
@@ -115,9 +122,10 @@ const (
 //
 //goland:noinspection GoSnakeCaseUsage
 const (
-	F_GETFD    = syscall.F_GETFD
-	F_SETFD    = syscall.F_SETFD
-	FD_CLOEXEC = syscall.FD_CLOEXEC
+	F_GETFD         = syscall.F_GETFD
+	F_SETFD         = syscall.F_SETFD
+	F_DUPFD_CLOEXEC = syscall.F_DUPFD_CLOEXEC // duplicate to the lowest fd >= arg, close-on-exec
+	FD_CLOEXEC      = syscall.FD_CLOEXEC
 )
 
 var (
@@ -0,0 +1,5 @@
+package posix
+
+// macOS has no MAP_FIXED_NOREPLACE. MmapAt passes its address as a plain hint
+// there and rejects the mapping if the kernel placed it elsewhere.
+const mapNoReplace = 0
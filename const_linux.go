@@ -50,3 +50,34 @@ const (
 	MS_BIND          = syscall.MS_BIND
 	MS_DIRSYNC       = syscall.MS_DIRSYNC
 )
+
+// Mapping flags newer than the syscall package. MAP_FIXED_NOREPLACE is what
+// MmapAt builds on; MAP_SYNC is only valid together with MAP_SHARED_VALIDATE.
+//
+//goland:noinspection GoSnakeCaseUsage
+const (
+	MAP_SHARED_VALIDATE = 0x3      // MAP_SHARED, but reject unknown flags (Linux 4.15+)
+	MAP_SYNC            = 0x80000  // synchronous page faults for DAX files (Linux 4.15+)
+	MAP_FIXED_NOREPLACE = 0x100000 // like MAP_FIXED, but fail with EEXIST instead of clobbering (Linux 4.17+)
+)
+
+// Advice newer than the syscall package; each has a wrapper on Madvise in
+// madvise_linux.go that reports an older kernel as errors.ErrUnsupported.
+//
+//goland:noinspection GoSnakeCaseUsage
+const (
+	MADV_FREE           = 8  // the pages may be freed lazily; until then they keep their contents (Linux 4.5+)
+	MADV_DONTDUMP       = 16 // leave the pages out of core dumps
+	MADV_DODUMP         = 17 // undo MADV_DONTDUMP
+	MADV_WIPEONFORK     = 18 // a child sees the pages zeroed (Linux 4.14+)
+	MADV_KEEPONFORK     = 19 // undo MADV_WIPEONFORK
+	MADV_COLD           = 20 // deactivate the pages: reclaim them first (Linux 5.4+)
+	MADV_PAGEOUT        = 21 // reclaim the pages now (Linux 5.4+)
+	MADV_POPULATE_READ  = 22 // fault the pages in for reading (Linux 5.14+)
+	MADV_POPULATE_WRITE = 23 // fault the pages in for writing (Linux 5.14+)
+	MADV_COLLAPSE       = 25 // collapse the pages into transparent huge pages now (Linux 6.1+)
+)
+
+// mapNoReplace is the flag MmapAt adds to make a fixed mapping fail rather
+// than replace what is already there.
+const mapNoReplace = MAP_FIXED_NOREPLACE
@@ -0,0 +1,116 @@
+package posix
+
+import (
+	"errors"
+	"os"
+)
+
+// Doorbell pairs a shared region, a MemfdCreate object, with an Event that
+// announces changes to it: one process writes into Bytes and rings, the other
+// waits for the ring and reads, and neither polls. Both descriptors go to a
+// child process together through Files, and the child picks them up with
+// OpenDoorbell.
+type Doorbell struct {
+	fd    int
+	event *Event
+	data  []byte
+}
+
+// NewDoorbell creates a doorbell over a fresh region of size bytes.
+func NewDoorbell(size int) (*Doorbell, error) {
+	if size <= 0 {
+		return nil, EINVAL
+	}
+	fd, err := MemfdCreate("doorbell", MFD_CLOEXEC)
+	if err != nil {
+		return nil, err
+	}
+	if err := Ftruncate(fd, size); err != nil {
+		_ = Close(fd)
+		return nil, err
+	}
+	event, err := Eventfd(0, EFD_CLOEXEC)
+	if err != nil {
+		_ = Close(fd)
+		return nil, err
+	}
+	d, err := openDoorbell(fd, event, size)
+	if err != nil {
+		_ = event.Close()
+		_ = Close(fd)
+	}
+	return d, err
+}
+
+// OpenDoorbell takes over the two descriptors of a doorbell, in the order
+// Files returns them, and maps its region.
+func OpenDoorbell(memfd int, eventfd int) (*Doorbell, error) {
+	var st Stat_t
+	if err := Fstat(memfd, &st); err != nil {
+		return nil, err
+	}
+	if st.Size <= 0 {
+		return nil, EINVAL
+	}
+	return openDoorbell(memfd, EventOf(eventfd), int(st.Size))
+}
+
+func openDoorbell(fd int, event *Event, size int) (*Doorbell, error) {
+	data, _, err := Mmap(nil, size, PROT_RDWR, MAP_SHARED, fd, 0)
+	if err != nil {
+		return nil, err
+	}
+	return &Doorbell{fd: fd, event: event, data: data}, nil
+}
+
+// Bytes returns the shared region, or nil once the doorbell is closed.
+func (d *Doorbell) Bytes() []byte {
+	return d.data
+}
+
+// Event returns the eventfd that rings the doorbell.
+func (d *Doorbell) Event() *Event {
+	return d.event
+}
+
+// Ring tells the other side that the region has changed.
+func (d *Doorbell) Ring() error {
+	return d.event.Signal(1)
+}
+
+// Wait waits for the doorbell to ring and returns how many times it rang since
+// the last Wait.
+func (d *Doorbell) Wait() (uint64, error) {
+	return d.event.Wait()
+}
+
+// Files returns duplicates of the region's descriptor and the eventfd, in that
+// order, for exec.Cmd.ExtraFiles: the child finds them at fds 3 and 4 and
+// passes those to OpenDoorbell. The caller closes them once the child has
+// started.
+func (d *Doorbell) Files() ([]*os.File, error) {
+	if d.fd < 0 {
+		return nil, ErrClosed
+	}
+	memfd, err := Fcntl(d.fd, F_DUPFD_CLOEXEC, 0)
+	if err != nil {
+		return nil, err
+	}
+	eventfd, err := Fcntl(d.event.fd, F_DUPFD_CLOEXEC, 0)
+	if err != nil {
+		_ = Close(memfd)
+		return nil, err
+	}
+	return []*os.File{os.NewFile(uintptr(memfd), "doorbell"), os.NewFile(uintptr(eventfd), "doorbell-event")}, nil
+}
+
+// Close unmaps the region and closes both descriptors. Afterwards Ring, Wait
+// and Files fail with ErrClosed, as does a second Close.
+func (d *Doorbell) Close() error {
+	if d.fd < 0 {
+		return ErrClosed
+	}
+	fd, data := d.fd, d.data
+	d.fd, d.data = -1, nil
+	return errors.Join(Munmap(data), d.event.Close(), Close(fd))
+}
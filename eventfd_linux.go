@@ -0,0 +1,80 @@
+package posix
+
+import "encoding/binary"
+
+//goland:noinspection GoSnakeCaseUsage
+const (
+	EFD_SEMAPHORE = 0x1        // Wait takes 1 from the counter instead of all of it
+	EFD_CLOEXEC   = O_CLOEXEC  // close the descriptor on exec
+	EFD_NONBLOCK  = O_NONBLOCK // Wait and Signal fail with EAGAIN instead of blocking
+)
+
+// Event is an eventfd: a 64-bit counter in the kernel that one side adds to
+// with Signal and the other side drains with Wait, sleeping while it is zero.
+// It replaces polling a counter in shared memory, and its descriptor can be
+// inherited or passed like any other.
+type Event struct {
+	fd int
+}
+
+// Eventfd creates an eventfd whose counter starts at initval. flags is a
+// combination of EFD_SEMAPHORE, EFD_NONBLOCK and EFD_CLOEXEC.
+func Eventfd(initval uint, flags int) (*Event, error) {
+	fd, err := eventfd(initval, flags)
+	if err != nil {
+		return nil, err
+	}
+	return &Event{fd: fd}, nil
+}
+
+// EventOf wraps an eventfd descriptor obtained some other way, such as one
+// inherited from a parent process.
+func EventOf(fd int) *Event {
+	return &Event{fd: fd}
+}
+
+// Fd returns the descriptor of e, or -1 once it is closed.
+func (e *Event) Fd() int {
+	return e.fd
+}
+
+// Signal adds n to the counter, waking a Wait. If that would overflow the
+// counter, Signal waits for a Wait to drain it, or fails with EAGAIN under
+// EFD_NONBLOCK. n must not be zero or the maximum uint64.
+func (e *Event) Signal(n uint64) error {
+	if e.fd < 0 {
+		return ErrClosed
+	}
+	if n == 0 || n == 1<<64-1 {
+		return EINVAL
+	}
+	var buf [8]byte
+	binary.NativeEndian.PutUint64(buf[:], n)
+	_, err := write(e.fd, buf[:])
+	return err
+}
+
+// Wait waits for the counter to be above zero, then returns it and resets it
+// to zero, or, under EFD_SEMAPHORE, returns 1 and takes 1 from it. Under
+// EFD_NONBLOCK it fails with EAGAIN instead of waiting.
+func (e *Event) Wait() (uint64, error) {
+	if e.fd < 0 {
+		return 0, ErrClosed
+	}
+	var buf [8]byte
+	if _, err := read(e.fd, buf[:]); err != nil {
+		return 0, err
+	}
+	return binary.NativeEndian.Uint64(buf[:]), nil
+}
+
+// Close closes the eventfd. A second Close returns ErrClosed rather than
+// closing whatever descriptor has reused the number.
+func (e *Event) Close() error {
+	if e.fd < 0 {
+		return ErrClosed
+	}
+	fd := e.fd
+	e.fd = -1
+	return Close(fd)
+}
@@ -0,0 +1,181 @@
+package posix_test
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+
+	"gopkg.in/ro-ag/posix.v1"
+)
+
+func TestEventfd(t *testing.T) {
+	e, err := posix.Eventfd(2, posix.EFD_NONBLOCK|posix.EFD_CLOEXEC)
+	if err != nil {
+		t.Fatalf("Eventfd: %v", err)
+	}
+	defer func() { _ = e.Close() }()
+	for _, n := range []uint64{3, 4} {
+		if err := e.Signal(n); err != nil {
+			t.Fatalf("Signal: %v", err)
+		}
+	}
+	if v, err := e.Wait(); err != nil || v != 9 {
+		t.Errorf("Wait = %d, %v, want the whole counter, 9", v, err)
+	}
+	if _, err := e.Wait(); !errors.Is(err, posix.EAGAIN) {
+		t.Errorf("Wait at zero = %v, want EAGAIN", err)
+	}
+	if err := e.Signal(0); !errors.Is(err, posix.EINVAL) {
+		t.Errorf("Signal(0) = %v, want EINVAL", err)
+	}
+
+	s, err := posix.Eventfd(2, posix.EFD_SEMAPHORE|posix.EFD_NONBLOCK)
+	if err != nil {
+		t.Fatalf("Eventfd: %v", err)
+	}
+	defer func() { _ = s.Close() }()
+	for range 2 {
+		if v, err := s.Wait(); err != nil || v != 1 {
+			t.Errorf("semaphore Wait = %d, %v, want 1", v, err)
+		}
+	}
+	if _, err := s.Wait(); !errors.Is(err, posix.EAGAIN) {
+		t.Errorf("semaphore Wait at zero = %v, want EAGAIN", err)
+	}
+
+	// A blocking Wait sleeps until a Signal.
+	b, err := posix.Eventfd(0, 0)
+	if err != nil {
+		t.Fatalf("Eventfd: %v", err)
+	}
+	defer func() { _ = b.Close() }()
+	done := make(chan uint64, 1)
+	go func() {
+		v, _ := b.Wait()
+		done <- v
+	}()
+	time.Sleep(10 * time.Millisecond)
+	if err := b.Signal(5); err != nil {
+		t.Fatalf("Signal: %v", err)
+	}
+	select {
+	case v := <-done:
+		if v != 5 {
+			t.Errorf("woken Wait = %d, want 5", v)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Wait not woken by Signal")
+	}
+}
+
+// TestEventClose: a second Close of an Event or a Doorbell returns ErrClosed
+// and leaves alone the descriptor that has reused the number.
+func TestEventClose(t *testing.T) {
+	e, err := posix.Eventfd(0, posix.EFD_NONBLOCK)
+	if err != nil {
+		t.Fatalf("Eventfd: %v", err)
+	}
+	if err := e.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	reused, err := posix.Eventfd(0, posix.EFD_NONBLOCK)
+	if err != nil {
+		t.Fatalf("Eventfd: %v", err)
+	}
+	defer func() { _ = reused.Close() }()
+	if err := e.Close(); !errors.Is(err, posix.ErrClosed) {
+		t.Errorf("second Close = %v, want ErrClosed", err)
+	}
+	if err := reused.Signal(1); err != nil {
+		t.Errorf("Signal on the descriptor that reused the number: %v", err)
+	}
+	if err := e.Signal(1); !errors.Is(err, posix.ErrClosed) || e.Fd() != -1 {
+		t.Errorf("Signal after Close = %v, Fd %d, want ErrClosed and -1", err, e.Fd())
+	}
+
+	d, err := posix.NewDoorbell(posix.Getpagesize())
+	if err != nil {
+		t.Fatalf("NewDoorbell: %v", err)
+	}
+	if err := d.Close(); err != nil {
+		t.Fatalf("Doorbell Close: %v", err)
+	}
+	if err := d.Close(); !errors.Is(err, posix.ErrClosed) {
+		t.Errorf("second Doorbell Close = %v, want ErrClosed", err)
+	}
+	if err := d.Ring(); !errors.Is(err, posix.ErrClosed) {
+		t.Errorf("Ring after Close = %v, want ErrClosed", err)
+	}
+	if _, err := d.Files(); !errors.Is(err, posix.ErrClosed) || d.Bytes() != nil {
+		t.Errorf("Files after Close = %v, Bytes of %d bytes, want ErrClosed and nil", err, len(d.Bytes()))
+	}
+}
+
+const doorbellChildEnv = "POSIX_DOORBELL_CHILD"
+
+// TestDoorbell hands a doorbell to a child process, which waits for it to ring
+// and answers in the region.
+func TestDoorbell(t *testing.T) {
+	if os.Getenv(doorbellChildEnv) != "" {
+		doorbellChild(t)
+		return
+	}
+	d, err := posix.NewDoorbell(posix.Getpagesize())
+	if err != nil {
+		t.Fatalf("NewDoorbell: %v", err)
+	}
+	defer func() { _ = d.Close() }()
+
+	files, err := d.Files()
+	if err != nil {
+		t.Fatalf("Files: %v", err)
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command(exe, "-test.run=^TestDoorbell$")
+	cmd.Env = append(os.Environ(), doorbellChildEnv+"=1")
+	cmd.ExtraFiles = files // fds 3 and 4 in the child
+	cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+	err = cmd.Start()
+	for _, f := range files {
+		_ = f.Close()
+	}
+	if err != nil {
+		t.Fatalf("start child: %v", err)
+	}
+
+	copy(d.Bytes(), "ping")
+	if err := d.Ring(); err != nil {
+		t.Fatalf("Ring: %v", err)
+	}
+	if err := cmd.Wait(); err != nil {
+		t.Fatalf("child: %v", err)
+	}
+	if got := string(d.Bytes()[:4]); got != "pong" {
+		t.Errorf("region after the child = %q, want %q", got, "pong")
+	}
+}
+
+// doorbellChild is the body of the re-executed child: wait for the ring, then
+// answer.
+func doorbellChild(t *testing.T) {
+	d, err := posix.OpenDoorbell(3, 4)
+	if err != nil {
+		t.Fatalf("child OpenDoorbell: %v", err)
+	}
+	defer func() { _ = d.Close() }()
+	if len(d.Bytes()) != posix.Getpagesize() {
+		t.Fatalf("child region is %d bytes, want %d", len(d.Bytes()), posix.Getpagesize())
+	}
+	if n, err := d.Wait(); err != nil || n != 1 {
+		t.Fatalf("child Wait = %d, %v, want 1", n, err)
+	}
+	if got := string(d.Bytes()[:4]); got != "ping" {
+		t.Fatalf("child read %q, want %q", got, "ping")
+	}
+	copy(d.Bytes(), "pong")
+}
@@ -2,7 +2,8 @@
 // with no cgo. The parent process creates a named POSIX shared-memory object,
 // writes a struct into it, then re-executes itself as a separate child process
 // that opens the same object by name and writes a reply back. The parent sees
-// the child's writes through the shared mapping.
+// the child's writes through the shared mapping. The parent publishes the base
+// address it mapped the object at, and the child maps it at the same address.
 //
 //	go run ./example/roundtrip
 package main
@@ -18,9 +19,11 @@ import (
 )
 
 // payload is the fixed-layout struct both processes share. It holds no
-// pointers, so its bytes mean the same thing in either process's address space.
+// pointers, so its bytes mean the same thing in either process's address space;
+// posix.View checks exactly that, along with its size and alignment.
 type payload struct {
 	Magic    uint64
+	Self     uint64 // the payload's own address, valid wherever the base is shared
 	Seq      uint64
 	ChildPID int64
 	Reply    [96]byte
@@ -41,7 +44,7 @@ func main() {
 }
 
 func parent() {
-	size := int(unsafe.Sizeof(payload{}))
+	size := posix.BaseHeaderSize + int(unsafe.Sizeof(payload{}))
 	name := fmt.Sprintf("/posix-rt-%d", os.Getpid())
 
 	// Create the named shared-memory object and give it a size.
@@ -54,17 +57,22 @@ func parent() {
 		log.Fatalf("parent Ftruncate: %v", err)
 	}
 
-	// Map it. The address argument is this package's differentiator: here we
-	// pass a hint and report what the kernel actually returned.
-	const hint = 0x20000000000
-	buf, addr, err := posix.Mmap(unsafe.Pointer(uintptr(hint)), size, posix.PROT_RDWR, posix.MAP_SHARED, fd, 0)
+	// Map it at a chosen base address and publish that address in the object's
+	// header. The address argument is this package's differentiator: with it,
+	// the child can map the object at the same place.
+	const base = 0x20000000000
+	buf, err := posix.PublishBase(fd, size, unsafe.Pointer(uintptr(base)), posix.PROT_RDWR)
 	if err != nil {
-		log.Fatalf("parent Mmap: %v", err)
+		log.Fatalf("parent PublishBase: %v", err)
 	}
-	log.Printf("parent: mapped %q at %#x (hint %#x)", name, addr, uintptr(hint))
+	log.Printf("parent: mapped %q at %#x", name, uintptr(unsafe.Pointer(&buf[0])))
 
-	p := (*payload)(unsafe.Pointer(&buf[0]))
+	p, err := posix.View[payload](buf, posix.BaseHeaderSize)
+	if err != nil {
+		log.Fatalf("parent View: %v", err)
+	}
 	p.Magic = magic
+	p.Self = uint64(uintptr(unsafe.Pointer(p)))
 	p.Seq = 42
 	log.Printf("parent: wrote Seq=%d", p.Seq)
 
@@ -96,23 +104,29 @@ func parent() {
 }
 
 func child(name string) {
-	size := int(unsafe.Sizeof(payload{}))
-
-	// Open the same object by name and map it. The address hint is nil here:
-	// the child only needs to see the bytes, not place them at a fixed address.
+	// Open the same object by name and map it at the base address the parent
+	// published. Should that range be taken in this process, AllowRelocate maps
+	// it elsewhere: the bytes are still shared, only absolute pointers break.
 	fd, err := posix.ShmOpen(name, posix.O_RDWR, 0)
 	if err != nil {
 		log.Fatalf("child ShmOpen: %v", err)
 	}
-	buf, _, err := posix.Mmap(nil, size, posix.PROT_RDWR, posix.MAP_SHARED, fd, 0)
+	buf, relocated, err := posix.AttachBase(fd, posix.PROT_RDWR, posix.AllowRelocate)
 	if err != nil {
-		log.Fatalf("child Mmap: %v", err)
+		log.Fatalf("child AttachBase: %v", err)
 	}
+	log.Printf("child: mapped %q at %#x (relocated=%v)", name, uintptr(unsafe.Pointer(&buf[0])), relocated)
 
-	p := (*payload)(unsafe.Pointer(&buf[0]))
+	p, err := posix.View[payload](buf, posix.BaseHeaderSize)
+	if err != nil {
+		log.Fatalf("child View: %v", err)
+	}
 	if p.Magic != magic {
 		log.Fatalf("child: bad magic %#x", p.Magic)
 	}
+	if !relocated && p.Self != uint64(uintptr(unsafe.Pointer(p))) {
+		log.Fatalf("child: payload at %p, but the parent saw it at %#x", p, p.Self)
+	}
 	if p.Seq != 42 {
 		log.Fatalf("child: expected Seq=42 from parent, got %d", p.Seq)
 	}
@@ -0,0 +1,150 @@
+//go:build linux
+
+// Command sharedsync demonstrates the futex-based synchronization primitives
+// working across processes. The parent lays a SharedMutex, SharedCond,
+// SharedBarrier and SharedRWMutex out in a named shared-memory object and
+// re-executes itself as several workers. The workers meet at the barrier,
+// increment a counter under the reader/writer lock, and report completion
+// through the mutex and condition variable, which the parent waits on.
+//
+//	go run ./example/sharedsync
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"time"
+	"unsafe"
+
+	"gopkg.in/ro-ag/posix.v1"
+)
+
+// state is the fixed-layout struct all processes share.
+type state struct {
+	Mu      posix.SharedMutex
+	Cond    posix.SharedCond
+	Barrier posix.SharedBarrier
+	RW      posix.SharedRWMutex
+	Counter uint64 // guarded by RW
+	Done    uint64 // guarded by Mu, signalled through Cond
+}
+
+const (
+	workers    = 4
+	increments = 10000
+	childEnv   = "POSIX_SHAREDSYNC_CHILD"
+)
+
+func main() {
+	log.SetFlags(0)
+	if name := os.Getenv(childEnv); name != "" {
+		worker(name)
+		return
+	}
+	parent()
+}
+
+// attach maps the object behind fd and returns the shared state in it.
+func attach(fd int) *state {
+	buf, _, err := posix.Mmap(nil, int(unsafe.Sizeof(state{})), posix.PROT_RDWR, posix.MAP_SHARED, fd, 0)
+	if err != nil {
+		log.Fatalf("Mmap: %v", err)
+	}
+	s, err := posix.View[state](buf, 0)
+	if err != nil {
+		log.Fatalf("View: %v", err)
+	}
+	return s
+}
+
+func parent() {
+	name := fmt.Sprintf("/posix-sync-%d", os.Getpid())
+	fd, err := posix.ShmOpen(name, posix.O_RDWR|posix.O_CREAT|posix.O_EXCL, posix.S_IRUSR|posix.S_IWUSR)
+	if err != nil {
+		log.Fatalf("parent ShmOpen: %v", err)
+	}
+	defer func() { _ = posix.ShmUnlink(name) }()
+	// A freshly sized object is all zeroes, which is an unlocked mutex, an
+	// idle condition variable and an unlocked RW mutex. Only the barrier needs
+	// to know how many will meet at it: the workers and the parent.
+	if err := posix.Ftruncate(fd, int(unsafe.Sizeof(state{}))); err != nil {
+		log.Fatalf("parent Ftruncate: %v", err)
+	}
+	s := attach(fd)
+	if err := s.Barrier.Init(workers + 1); err != nil {
+		log.Fatalf("parent Barrier.Init: %v", err)
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		log.Fatalf("parent Executable: %v", err)
+	}
+	var cmds []*exec.Cmd
+	for range workers {
+		cmd := exec.Command(exe)
+		cmd.Env = append(os.Environ(), childEnv+"="+name)
+		cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+		if err := cmd.Start(); err != nil {
+			log.Fatalf("parent: start worker: %v", err)
+		}
+		cmds = append(cmds, cmd)
+	}
+
+	if _, err := s.Barrier.Wait(); err != nil {
+		log.Fatalf("parent Barrier.Wait: %v", err)
+	}
+	log.Printf("parent: all %d workers at the barrier", workers)
+
+	// Wait, without spinning, for every worker to report.
+	if err := s.Mu.Lock(); err != nil {
+		log.Fatalf("parent Lock: %v", err)
+	}
+	for s.Done < workers {
+		if err := s.Cond.Wait(&s.Mu, 10*time.Second); err != nil {
+			log.Fatalf("parent Cond.Wait: %v (done=%d)", err, s.Done)
+		}
+	}
+	if err := s.Mu.Unlock(); err != nil {
+		log.Fatalf("parent Unlock: %v", err)
+	}
+
+	s.RW.RLock()
+	counter := s.Counter
+	s.RW.RUnlock()
+	for _, cmd := range cmds {
+		if err := cmd.Wait(); err != nil {
+			log.Fatalf("parent: worker failed: %v", err)
+		}
+	}
+	if counter != workers*increments {
+		log.Fatalf("parent: counter = %d, want %d", counter, workers*increments)
+	}
+	log.Printf("parent: counter = %d", counter)
+	fmt.Println("sync OK")
+}
+
+func worker(name string) {
+	fd, err := posix.ShmOpen(name, posix.O_RDWR, 0)
+	if err != nil {
+		log.Fatalf("worker ShmOpen: %v", err)
+	}
+	s := attach(fd)
+	if _, err := s.Barrier.Wait(); err != nil {
+		log.Fatalf("worker Barrier.Wait: %v", err)
+	}
+	for range increments {
+		s.RW.Lock()
+		s.Counter++
+		s.RW.Unlock()
+	}
+	if err := s.Mu.Lock(); err != nil {
+		log.Fatalf("worker Lock: %v", err)
+	}
+	s.Done++
+	s.Cond.Broadcast()
+	if err := s.Mu.Unlock(); err != nil {
+		log.Fatalf("worker Unlock: %v", err)
+	}
+}
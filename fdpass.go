@@ -0,0 +1,159 @@
+//go:build darwin || linux
+
+package posix
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"net"
+	"syscall"
+)
+
+// MaxFds is the most descriptors one SendFds message can carry: the kernel's
+// SCM_MAX_FD on Linux.
+const MaxFds = 253
+
+// ErrTooManyFds is returned by SendFds for more than MaxFds descriptors, and by
+// RecvFds for a message carrying more descriptors than the caller allowed.
+var ErrTooManyFds = errors.New("posix: too many descriptors")
+
+// fdHeader frames each SendFds message: the length of the metadata and the
+// number of descriptors, so the receiver can tell where a message ends on a
+// stream socket and check it received every descriptor sent.
+const fdHeader = 8
+
+// SendFds sends copies of fds, with meta, over a Unix socket as SCM_RIGHTS
+// ancillary data. The receiving process gets its own descriptors for the same
+// open files; the caller may close its own once SendFds returns. This is how
+// an unnamed object, such as a MemfdCreate one, reaches a process that did not
+// inherit it.
+func SendFds(conn *net.UnixConn, fds []int, meta []byte) error {
+	if len(fds) > MaxFds {
+		return ErrTooManyFds
+	}
+	if len(meta) > math.MaxUint32-fdHeader {
+		return EINVAL
+	}
+	msg := make([]byte, fdHeader+len(meta))
+	binary.LittleEndian.PutUint32(msg[0:], uint32(len(meta)))
+	binary.LittleEndian.PutUint32(msg[4:], uint32(len(fds)))
+	copy(msg[fdHeader:], meta)
+	var oob []byte
+	if len(fds) > 0 {
+		oob = syscall.UnixRights(fds...)
+	}
+	n, _, err := conn.WriteMsgUnix(msg, oob, nil)
+	if err != nil {
+		return err
+	}
+	// The descriptors went with the first byte; a stream socket may take the
+	// rest of a long message separately.
+	if n < len(msg) {
+		_, err = conn.Write(msg[n:])
+	}
+	return err
+}
+
+// RecvFds receives one SendFds message, accepting at most maxFds descriptors.
+// It copies the metadata into meta and returns its length, and returns the
+// received descriptors, which are close-on-exec and belong to the caller. A
+// message with more than maxFds descriptors fails with ErrTooManyFds, and one
+// whose metadata does not fit in meta with io.ErrShortBuffer; either way the
+// message is consumed and its descriptors are closed. At the end of the
+// stream, RecvFds returns io.EOF.
+func RecvFds(conn *net.UnixConn, meta []byte, maxFds int) (n int, fds []int, err error) {
+	if maxFds < 0 || maxFds > MaxFds {
+		return 0, nil, EINVAL
+	}
+	defer func() {
+		if err != nil {
+			for _, fd := range fds {
+				_ = Close(fd)
+			}
+			fds = nil
+		}
+	}()
+
+	// On a stream socket, read just the header, which the descriptors arrive
+	// with, and then exactly the metadata. A datagram or seqpacket message has
+	// to be read in one go.
+	stream := conn.LocalAddr().Network() == "unix"
+	buf := make([]byte, fdHeader+len(meta))
+	if stream {
+		buf = buf[:fdHeader]
+	}
+	oob := make([]byte, syscall.CmsgSpace(max(maxFds, 1)*4))
+	got, oobn, flags, _, err := conn.ReadMsgUnix(buf, oob)
+	if oobn > 0 {
+		fds, err = parseRights(oob[:oobn], err)
+	}
+	if err != nil {
+		return 0, fds, err
+	}
+	if got == 0 {
+		return 0, fds, io.EOF
+	}
+	if stream && got < fdHeader {
+		if _, err = io.ReadFull(conn, buf[got:fdHeader]); err != nil {
+			return 0, fds, err
+		}
+		got = fdHeader
+	}
+	if got < fdHeader {
+		return 0, fds, io.ErrUnexpectedEOF
+	}
+	metaLen := int(binary.LittleEndian.Uint32(buf[0:]))
+	fdCount := int(binary.LittleEndian.Uint32(buf[4:]))
+	if stream {
+		// Consume the whole message, even one that will be refused below, so
+		// the next RecvFds starts at a message boundary.
+		body := meta[:min(metaLen, len(meta))]
+		if _, err = io.ReadFull(conn, body); err != nil {
+			return 0, fds, err
+		}
+		if metaLen > len(meta) {
+			if _, err = io.CopyN(io.Discard, conn, int64(metaLen-len(meta))); err != nil {
+				return 0, fds, err
+			}
+		}
+	} else {
+		if flags&syscall.MSG_TRUNC != 0 && metaLen <= len(meta) {
+			return 0, fds, io.ErrUnexpectedEOF
+		}
+		copy(meta, buf[fdHeader:got])
+	}
+	if flags&syscall.MSG_CTRUNC != 0 || fdCount > maxFds {
+		return 0, fds, ErrTooManyFds
+	}
+	if metaLen > len(meta) {
+		return 0, fds, io.ErrShortBuffer
+	}
+	if fdCount != len(fds) {
+		return 0, fds, io.ErrUnexpectedEOF
+	}
+	return metaLen, fds, nil
+}
+
+// parseRights returns the descriptors in the SCM_RIGHTS messages in oob. The
+// descriptors are now the caller's, so even on error all that could be parsed
+// are returned, for closing.
+func parseRights(oob []byte, err error) ([]int, error) {
+	msgs, perr := syscall.ParseSocketControlMessage(oob)
+	if perr != nil {
+		return nil, errors.Join(err, perr)
+	}
+	var fds []int
+	for i := range msgs {
+		if msgs[i].Header.Level != syscall.SOL_SOCKET || msgs[i].Header.Type != syscall.SCM_RIGHTS {
+			continue
+		}
+		rights, perr := syscall.ParseUnixRights(&msgs[i])
+		if perr != nil {
+			return fds, errors.Join(err, perr)
+		}
+		fds = append(fds, rights...)
+	}
+	return fds, err
+}
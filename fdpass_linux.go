@@ -0,0 +1,92 @@
+package posix
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+)
+
+// ErrNotSealed is returned by RecvMemfd for an object missing a required seal.
+var ErrNotSealed = errors.New("posix: object lacks required seals")
+
+// ErrNoMemfd is returned by RecvMemfd for a message that is not a SendMemfd
+// handshake.
+var ErrNoMemfd = errors.New("posix: not a memfd handshake")
+
+const (
+	memfdMagic   = 0x4446_4d45_4d58_4953 // "SIXMEMFD"
+	memfdVersion = 1
+	memfdHello   = 24 // magic, version, seals, size
+)
+
+// SendMemfd sends fd, a MemfdCreate object, over a Unix socket together with
+// its size and seals, for RecvMemfd in a process that neither created nor
+// inherited it. Seal the object first: a receiver that does not trust the
+// sender only accepts it sealed against the changes it cannot tolerate, such
+// as F_SEAL_SHRINK, which would otherwise let the sender make its mappings
+// fault.
+func SendMemfd(conn *net.UnixConn, fd int) error {
+	var st Stat_t
+	if err := Fstat(fd, &st); err != nil {
+		return err
+	}
+	seals, err := Seals(fd)
+	if err != nil {
+		return err
+	}
+	var hello [memfdHello]byte
+	binary.LittleEndian.PutUint64(hello[0:], memfdMagic)
+	binary.LittleEndian.PutUint32(hello[8:], memfdVersion)
+	binary.LittleEndian.PutUint32(hello[12:], uint32(seals))
+	binary.LittleEndian.PutUint64(hello[16:], uint64(st.Size))
+	return SendFds(conn, []int{fd}, hello[:])
+}
+
+// RecvMemfd receives an object sent with SendMemfd and returns its descriptor,
+// size and seals. It fails with ErrNotSealed unless the object carries every
+// seal in required. The size and seals are those of the object itself, read
+// from the kernel after it arrives and checked against the sender's
+// announcement, not the sender's word for them; since seals cannot be
+// removed, the ones returned stay in force.
+func RecvMemfd(conn *net.UnixConn, required int) (fd int, size int, seals int, err error) {
+	var hello [memfdHello]byte
+	n, fds, err := RecvFds(conn, hello[:], 1)
+	if err != nil {
+		return -1, 0, 0, err
+	}
+	if len(fds) != 1 {
+		return -1, 0, 0, ErrNoMemfd
+	}
+	fd = fds[0]
+	defer func() {
+		if err != nil {
+			_ = Close(fd)
+			fd = -1
+		}
+	}()
+	if n != memfdHello || binary.LittleEndian.Uint64(hello[0:]) != memfdMagic {
+		return fd, 0, 0, ErrNoMemfd
+	}
+	if v := binary.LittleEndian.Uint32(hello[8:]); v != memfdVersion {
+		return fd, 0, 0, fmt.Errorf("%w: unsupported version %d", ErrNoMemfd, v)
+	}
+	if seals, err = Seals(fd); err != nil {
+		return fd, 0, 0, err
+	}
+	var st Stat_t
+	if err = Fstat(fd, &st); err != nil {
+		return fd, 0, 0, err
+	}
+	// Seals are never removed, and a size sealed both ways never changes, so
+	// the object must still match what the sender announced.
+	claimed := int(binary.LittleEndian.Uint32(hello[12:]))
+	fixed := F_SEAL_SHRINK | F_SEAL_GROW
+	if seals&claimed != claimed || claimed&fixed == fixed && uint64(st.Size) != binary.LittleEndian.Uint64(hello[16:]) {
+		return fd, 0, 0, fmt.Errorf("%w: object does not match the announcement", ErrNoMemfd)
+	}
+	if seals&required != required {
+		return fd, 0, 0, fmt.Errorf("%w: have %#x, need %#x", ErrNotSealed, seals, required)
+	}
+	return fd, int(st.Size), seals, nil
+}
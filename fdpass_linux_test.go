@@ -0,0 +1,128 @@
+package posix_test
+
+import (
+	"errors"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+
+	"gopkg.in/ro-ag/posix.v1"
+)
+
+// sealedMemfd returns a memfd of one page holding text, sealed with seals.
+func sealedMemfd(t *testing.T, text string, seals int) int {
+	t.Helper()
+	fd, err := posix.MemfdCreate("handshake", posix.MFD_ALLOW_SEALING)
+	if err != nil {
+		t.Fatalf("MemfdCreate: %v", err)
+	}
+	t.Cleanup(func() { _ = posix.Close(fd) })
+	if err := posix.Ftruncate(fd, posix.Getpagesize()); err != nil {
+		t.Fatal(err)
+	}
+	b, _, err := posix.Mmap(nil, posix.Getpagesize(), posix.PROT_RDWR, posix.MAP_SHARED, fd, 0)
+	if err != nil {
+		t.Fatalf("Mmap: %v", err)
+	}
+	copy(b, text)
+	_ = posix.Munmap(b)
+	if err := posix.AddSeals(fd, seals); err != nil {
+		t.Fatalf("AddSeals: %v", err)
+	}
+	return fd
+}
+
+func TestRecvMemfdSeals(t *testing.T) {
+	a, b := unixPair(t, syscall.SOCK_STREAM)
+	loose := sealedMemfd(t, "loose", posix.F_SEAL_GROW)
+	if err := posix.SendMemfd(a, loose); err != nil {
+		t.Fatalf("SendMemfd: %v", err)
+	}
+	if fd, _, _, err := posix.RecvMemfd(b, posix.F_SEAL_SHRINK|posix.F_SEAL_GROW); !errors.Is(err, posix.ErrNotSealed) || fd != -1 {
+		t.Errorf("RecvMemfd of an object that can shrink = %d, %v, want ErrNotSealed", fd, err)
+	}
+
+	// Plain SendFds metadata is not a handshake.
+	if err := posix.SendFds(a, []int{loose}, []byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, _, err := posix.RecvMemfd(b, 0); !errors.Is(err, posix.ErrNoMemfd) {
+		t.Errorf("RecvMemfd of a plain message = %v, want ErrNoMemfd", err)
+	}
+}
+
+const memfdChildEnv = "POSIX_MEMFD_CHILD"
+
+// TestMemfdHandshake hands a sealed memfd to a process that did not inherit
+// it: a child that connects to a listening socket by path, as an unrelated
+// process would.
+func TestMemfdHandshake(t *testing.T) {
+	if path := os.Getenv(memfdChildEnv); path != "" {
+		memfdChild(t, path)
+		return
+	}
+	path := filepath.Join(t.TempDir(), "memfd.sock")
+	l, err := net.ListenUnix("unix", &net.UnixAddr{Name: path, Net: "unix"})
+	if err != nil {
+		t.Fatalf("ListenUnix: %v", err)
+	}
+	defer func() { _ = l.Close() }()
+
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command(exe, "-test.run=^TestMemfdHandshake$", "-test.v")
+	cmd.Env = append(os.Environ(), memfdChildEnv+"="+path)
+	var out strings.Builder
+	cmd.Stdout, cmd.Stderr = &out, &out
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("start child: %v", err)
+	}
+	conn, err := l.AcceptUnix()
+	if err != nil {
+		t.Fatalf("AcceptUnix: %v", err)
+	}
+	defer func() { _ = conn.Close() }()
+	fd := sealedMemfd(t, "sealed region", posix.F_SEAL_SHRINK|posix.F_SEAL_GROW|posix.F_SEAL_WRITE|posix.F_SEAL_SEAL)
+	if err := posix.SendMemfd(conn, fd); err != nil {
+		t.Fatalf("SendMemfd: %v", err)
+	}
+	if err := cmd.Wait(); err != nil {
+		t.Fatalf("child: %v\n%s", err, out.String())
+	}
+	if !strings.Contains(out.String(), "received sealed region") {
+		t.Errorf("child output:\n%s", out.String())
+	}
+}
+
+// memfdChild is the body of the re-executed child: connect, receive the
+// object, check it and report its contents.
+func memfdChild(t *testing.T, path string) {
+	conn, err := net.DialUnix("unix", nil, &net.UnixAddr{Name: path, Net: "unix"})
+	if err != nil {
+		t.Fatalf("child DialUnix: %v", err)
+	}
+	defer func() { _ = conn.Close() }()
+	fd, size, seals, err := posix.RecvMemfd(conn, posix.F_SEAL_SHRINK|posix.F_SEAL_WRITE)
+	if err != nil {
+		t.Fatalf("child RecvMemfd: %v", err)
+	}
+	defer func() { _ = posix.Close(fd) }()
+	if size != posix.Getpagesize() || seals&posix.F_SEAL_GROW == 0 {
+		t.Fatalf("child RecvMemfd size %d, seals %#x", size, seals)
+	}
+	if _, _, err := posix.Mmap(nil, size, posix.PROT_RDWR, posix.MAP_SHARED, fd, 0); !errors.Is(err, posix.EPERM) {
+		t.Fatalf("child writable Mmap of a write-sealed object = %v, want EPERM", err)
+	}
+	b, _, err := posix.Mmap(nil, size, posix.PROT_READ, posix.MAP_SHARED, fd, 0)
+	if err != nil {
+		t.Fatalf("child Mmap: %v", err)
+	}
+	defer func() { _ = posix.Munmap(b) }()
+	t.Logf("received %s", strings.TrimRight(string(b), "\x00"))
+}
@@ -0,0 +1,134 @@
+//go:build darwin || linux
+
+package posix_test
+
+import (
+	"errors"
+	"io"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+
+	"gopkg.in/ro-ag/posix.v1"
+)
+
+// unixPair returns the two ends of a connected Unix socket pair of type typ.
+func unixPair(t *testing.T, typ int) (*net.UnixConn, *net.UnixConn) {
+	t.Helper()
+	fds, err := syscall.Socketpair(syscall.AF_UNIX, typ, 0)
+	if err != nil {
+		t.Fatalf("Socketpair: %v", err)
+	}
+	var conns [2]*net.UnixConn
+	for i, fd := range fds {
+		f := os.NewFile(uintptr(fd), "socket")
+		c, err := net.FileConn(f)
+		_ = f.Close()
+		if err != nil {
+			t.Fatalf("FileConn: %v", err)
+		}
+		conns[i] = c.(*net.UnixConn)
+		t.Cleanup(func() { _ = c.Close() })
+	}
+	return conns[0], conns[1]
+}
+
+// TestSendFds passes two memory objects and metadata, and checks that what
+// arrives are working descriptors for the same objects.
+func TestSendFds(t *testing.T) {
+	for _, typ := range []struct {
+		name string
+		typ  int
+	}{{"stream", syscall.SOCK_STREAM}, {"dgram", syscall.SOCK_DGRAM}} {
+		t.Run(typ.name, func(t *testing.T) {
+			a, b := unixPair(t, typ.typ)
+			pg := posix.Getpagesize()
+			var sent []int
+			for range 2 {
+				fd, err := posix.MemfdCreate("fdpass", 0)
+				if err != nil {
+					t.Fatalf("MemfdCreate: %v", err)
+				}
+				defer func() { _ = posix.Close(fd) }()
+				if err := posix.Ftruncate(fd, pg); err != nil {
+					t.Fatal(err)
+				}
+				sent = append(sent, fd)
+			}
+			if err := posix.SendFds(a, sent, []byte("two objects")); err != nil {
+				t.Fatalf("SendFds: %v", err)
+			}
+			if err := posix.SendFds(a, nil, []byte("none")); err != nil {
+				t.Fatalf("SendFds without descriptors: %v", err)
+			}
+
+			meta := make([]byte, 64)
+			n, fds, err := posix.RecvFds(b, meta, 2)
+			if err != nil {
+				t.Fatalf("RecvFds: %v", err)
+			}
+			if string(meta[:n]) != "two objects" || len(fds) != 2 {
+				t.Fatalf("RecvFds = %q, %d fds, want \"two objects\", 2 fds", meta[:n], len(fds))
+			}
+			for i, fd := range fds {
+				defer func() { _ = posix.Close(fd) }()
+				// Writing through the received descriptor shows in the original.
+				out, _, err := posix.Mmap(nil, pg, posix.PROT_RDWR, posix.MAP_SHARED, fd, 0)
+				if err != nil {
+					t.Fatalf("Mmap received fd: %v", err)
+				}
+				out[0] = byte('A' + i)
+				_ = posix.Munmap(out)
+				in, _, err := posix.Mmap(nil, pg, posix.PROT_READ, posix.MAP_SHARED, sent[i], 0)
+				if err != nil {
+					t.Fatalf("Mmap sent fd: %v", err)
+				}
+				if in[0] != byte('A'+i) {
+					t.Errorf("object %d reads %q through the sent fd, want %q", i, in[0], byte('A'+i))
+				}
+				_ = posix.Munmap(in)
+			}
+
+			n, fds, err = posix.RecvFds(b, meta, 2)
+			if err != nil || string(meta[:n]) != "none" || len(fds) != 0 {
+				t.Errorf("RecvFds = %q, %v, %v, want \"none\" and no fds", meta[:n], fds, err)
+			}
+
+			// Too many descriptors, or too much metadata, is refused, and the
+			// next message still arrives intact.
+			if err := posix.SendFds(a, sent, nil); err != nil {
+				t.Fatal(err)
+			}
+			if _, fds, err := posix.RecvFds(b, meta, 1); !errors.Is(err, posix.ErrTooManyFds) || fds != nil {
+				t.Errorf("RecvFds of 2 fds with a limit of 1 = %v, %v, want ErrTooManyFds", fds, err)
+			}
+			if err := posix.SendFds(a, sent[:1], []byte("too long")); err != nil {
+				t.Fatal(err)
+			}
+			if _, fds, err := posix.RecvFds(b, meta[:3], 1); !errors.Is(err, io.ErrShortBuffer) || fds != nil {
+				t.Errorf("RecvFds into a short buffer = %v, %v, want io.ErrShortBuffer", fds, err)
+			}
+			if err := posix.SendFds(a, nil, []byte("after")); err != nil {
+				t.Fatal(err)
+			}
+			if n, _, err := posix.RecvFds(b, meta, 1); err != nil || string(meta[:n]) != "after" {
+				t.Errorf("RecvFds after refused messages = %q, %v, want \"after\"", meta[:n], err)
+			}
+		})
+	}
+}
+
+func TestSendFdsLimits(t *testing.T) {
+	a, b := unixPair(t, syscall.SOCK_STREAM)
+	if err := posix.SendFds(a, make([]int, posix.MaxFds+1), nil); !errors.Is(err, posix.ErrTooManyFds) {
+		t.Errorf("SendFds of MaxFds+1 = %v, want ErrTooManyFds", err)
+	}
+	if _, _, err := posix.RecvFds(b, nil, posix.MaxFds+1); !errors.Is(err, posix.EINVAL) {
+		t.Errorf("RecvFds allowing MaxFds+1 = %v, want EINVAL", err)
+	}
+	_ = a.Close()
+	if _, _, err := posix.RecvFds(b, nil, 1); err != io.EOF {
+		t.Errorf("RecvFds at the end of the stream = %v, want io.EOF", err)
+	}
+}
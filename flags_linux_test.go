@@ -49,3 +49,20 @@ func TestMemfdNonSealableReportsSealSeal(t *testing.T) {
 		t.Errorf("Seals on a non-sealable memfd = %#x, want F_SEAL_SEAL (%#x)", seals, posix.F_SEAL_SEAL)
 	}
 }
+
+// TestMapFlagConstantValues pins the mapping flags that are hard-coded because
+// the syscall package predates them.
+func TestMapFlagConstantValues(t *testing.T) {
+	for _, c := range []struct {
+		name      string
+		got, want int
+	}{
+		{"MAP_SHARED_VALIDATE", posix.MAP_SHARED_VALIDATE, 0x03},
+		{"MAP_SYNC", posix.MAP_SYNC, 0x80000},
+		{"MAP_FIXED_NOREPLACE", posix.MAP_FIXED_NOREPLACE, 0x100000},
+	} {
+		if c.got != c.want {
+			t.Errorf("%s = %#x, want %#x", c.name, c.got, c.want)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package posix
+
+import "time"
+
+//goland:noinspection GoSnakeCaseUsage
+const (
+	FUTEX_WAIT             = 0
+	FUTEX_WAKE             = 1
+	FUTEX_REQUEUE          = 3
+	FUTEX_CMP_REQUEUE      = 4
+	FUTEX_WAKE_OP          = 5
+	FUTEX_LOCK_PI          = 6
+	FUTEX_UNLOCK_PI        = 7
+	FUTEX_TRYLOCK_PI       = 8
+	FUTEX_WAIT_BITSET      = 9
+	FUTEX_WAKE_BITSET      = 10
+	FUTEX_PRIVATE_FLAG     = 128 // the futex word is not shared with other processes
+	FUTEX_CLOCK_REALTIME   = 256 // FUTEX_WAIT_BITSET timeouts are CLOCK_REALTIME, not CLOCK_MONOTONIC
+	FUTEX_BITSET_MATCH_ANY = 0xffffffff
+)
+
+// Futex is the raw futex(2) system call. The meaning of val, timeout, addr2 and
+// val3 depends on op; see futex(2). FutexWait, FutexWake and their bitset
+// variants cover the common operations.
+func Futex(addr *uint32, op int, val uint32, timeout *Timespec, addr2 *uint32, val3 uint32) (int, error) {
+	return futex(addr, op, val, timeout, addr2, val3)
+}
+
+// FutexWait blocks while *addr holds val, until a FutexWake on the same word —
+// from any process that maps it — or until timeout elapses; a negative timeout
+// waits indefinitely. It fails with EAGAIN if *addr did not hold val to begin
+// with, ETIMEDOUT on timeout, and EINTR if a signal interrupted it (the Go
+// runtime sends them routinely). Wakeups may also be spurious: callers re-check
+// the word in a loop.
+//
+// The futex is shared, not FUTEX_PRIVATE_FLAG, so addr may lie in memory
+// mapped by several processes. Use Futex directly for the private form.
+func FutexWait(addr *uint32, val uint32, timeout time.Duration) error {
+	var ts *Timespec
+	if timeout >= 0 {
+		ts = &Timespec{Sec: int64(timeout / time.Second), Nsec: int64(timeout % time.Second)}
+	}
+	_, err := futex(addr, FUTEX_WAIT, val, ts, nil, 0)
+	return err
+}
+
+// FutexWake wakes up to n waiters on addr and returns how many it woke.
+func FutexWake(addr *uint32, n int) (int, error) {
+	return futex(addr, FUTEX_WAKE, uint32(min(n, 1<<31-1)), nil, nil, 0)
+}
+
+// FutexWaitBitset is FutexWait for waiters that only FutexWakeBitset with an
+// overlapping mask can wake. Its timeout is an absolute deadline on the
+// realtime clock; the zero Time waits indefinitely.
+func FutexWaitBitset(addr *uint32, val uint32, deadline time.Time, mask uint32) error {
+	_, err := futex(addr, FUTEX_WAIT_BITSET|FUTEX_CLOCK_REALTIME, val, deadlineTimespec(deadline), nil, mask)
+	return err
+}
+
+// FutexWakeBitset wakes up to n waiters on addr whose mask overlaps mask, and
+// returns how many it woke. FUTEX_BITSET_MATCH_ANY wakes them regardless.
+func FutexWakeBitset(addr *uint32, n int, mask uint32) (int, error) {
+	return futex(addr, FUTEX_WAKE_BITSET, uint32(min(n, 1<<31-1)), nil, nil, mask)
+}
+
+// deadlineTimespec converts an absolute deadline for a system call, which
+// takes nil to mean no deadline.
+func deadlineTimespec(deadline time.Time) *Timespec {
+	if deadline.IsZero() {
+		return nil
+	}
+	return &Timespec{Sec: deadline.Unix(), Nsec: int64(deadline.Nanosecond())}
+}
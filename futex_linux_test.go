@@ -0,0 +1,116 @@
+package posix_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+	"unsafe"
+
+	"gopkg.in/ro-ag/posix.v1"
+)
+
+// futexPair maps one page of a memfd twice and returns the same futex word as
+// seen through each mapping, as two processes would see it.
+func futexPair(t *testing.T) (a, b *uint32) {
+	t.Helper()
+	pg := posix.Getpagesize()
+	fd, err := posix.MemfdCreate("futex", 0)
+	if err != nil {
+		t.Fatalf("MemfdCreate: %v", err)
+	}
+	t.Cleanup(func() { _ = posix.Close(fd) })
+	if err := posix.Ftruncate(fd, pg); err != nil {
+		t.Fatal(err)
+	}
+	var words [2]*uint32
+	for i := range words {
+		m, _, err := posix.Mmap(nil, pg, posix.PROT_RDWR, posix.MAP_SHARED, fd, 0)
+		if err != nil {
+			t.Fatalf("Mmap: %v", err)
+		}
+		t.Cleanup(func() { _ = posix.Munmap(m) })
+		words[i] = (*uint32)(unsafe.Pointer(&m[0]))
+	}
+	return words[0], words[1]
+}
+
+// wakeUntil wakes addr until one waiter has been woken, since the waiter may
+// not have reached the kernel yet.
+func wakeUntil(t *testing.T, wake func() (int, error)) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		n, err := wake()
+		if err != nil {
+			t.Fatalf("wake: %v", err)
+		}
+		if n == 1 {
+			return
+		}
+	}
+	t.Fatal("no waiter to wake")
+}
+
+// TestFutexWaitWake: a waiter blocked through one mapping is woken through the
+// other, which only works for a shared (not FUTEX_PRIVATE_FLAG) futex.
+func TestFutexWaitWake(t *testing.T) {
+	a, b := futexPair(t)
+	done := make(chan error, 1)
+	go func() {
+		for {
+			err := posix.FutexWait(a, 0, -1)
+			if !errors.Is(err, posix.EINTR) {
+				done <- err
+				return
+			}
+		}
+	}()
+	wakeUntil(t, func() (int, error) { return posix.FutexWake(b, 1) })
+	if err := <-done; err != nil {
+		t.Errorf("FutexWait returned %v, want nil after FutexWake", err)
+	}
+}
+
+// TestFutexWaitErrors: a stale value returns at once with EAGAIN, and a timeout
+// with ETIMEDOUT.
+func TestFutexWaitErrors(t *testing.T) {
+	a, _ := futexPair(t)
+	*a = 1
+	if err := posix.FutexWait(a, 0, -1); !errors.Is(err, posix.EAGAIN) {
+		t.Errorf("FutexWait on a changed word = %v, want EAGAIN", err)
+	}
+	start := time.Now()
+	err := posix.FutexWait(a, 1, 20*time.Millisecond)
+	for errors.Is(err, posix.EINTR) {
+		err = posix.FutexWait(a, 1, 20*time.Millisecond)
+	}
+	if !errors.Is(err, posix.ETIMEDOUT) || time.Since(start) < 20*time.Millisecond {
+		t.Errorf("FutexWait with a timeout = %v after %v, want ETIMEDOUT after 20ms", err, time.Since(start))
+	}
+	if err := posix.FutexWaitBitset(a, 1, time.Now().Add(-time.Second), 1); !errors.Is(err, posix.ETIMEDOUT) {
+		t.Errorf("FutexWaitBitset past its deadline = %v, want ETIMEDOUT", err)
+	}
+}
+
+// TestFutexBitset: a wake whose mask does not overlap the waiter's leaves it
+// waiting.
+func TestFutexBitset(t *testing.T) {
+	a, b := futexPair(t)
+	done := make(chan error, 1)
+	go func() {
+		for {
+			err := posix.FutexWaitBitset(a, 0, time.Time{}, 0b01)
+			if !errors.Is(err, posix.EINTR) {
+				done <- err
+				return
+			}
+		}
+	}()
+	time.Sleep(20 * time.Millisecond)
+	if n, err := posix.FutexWakeBitset(b, 1, 0b10); err != nil || n != 0 {
+		t.Errorf("FutexWakeBitset with a disjoint mask woke %d, %v; want 0", n, err)
+	}
+	wakeUntil(t, func() (int, error) { return posix.FutexWakeBitset(b, 1, 0b11) })
+	if err := <-done; err != nil {
+		t.Errorf("FutexWaitBitset returned %v, want nil", err)
+	}
+}
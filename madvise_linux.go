@@ -0,0 +1,84 @@
+package posix
+
+import (
+	"errors"
+	"fmt"
+)
+
+// madviseNew gives advice that older kernels may not know. They reject it
+// with EINVAL, as they do a misaligned range or advice that does not apply to
+// the mapping, so on EINVAL the advice is tried alone on an empty range: the
+// kernel validates the advice before anything else and then does nothing. If
+// that fails too, the advice is what is missing.
+func madviseNew(b []byte, advice int, name string) error {
+	err := madvise(b, advice)
+	if err != EINVAL {
+		return err
+	}
+	if _, _, e1 := _Syscall(_SYS_MADVISE, 0, 0, uintptr(advice)); e1 == 0 {
+		return err
+	}
+	return fmt.Errorf("posix: %s unsupported by this kernel: %w (%w)", name, errors.ErrUnsupported, EINVAL)
+}
+
+// MadviseFree tells the kernel it may free the pages of b, a private anonymous
+// mapping, whenever it needs the memory; until it does they keep their
+// contents, and writing to a page keeps it. It is cheaper than MADV_DONTNEED
+// for memory that will soon be reused.
+func MadviseFree(b []byte) error {
+	return madviseNew(b, MADV_FREE, "MADV_FREE")
+}
+
+// MadviseDontDump leaves the pages of b out of core dumps.
+func MadviseDontDump(b []byte) error {
+	return madviseNew(b, MADV_DONTDUMP, "MADV_DONTDUMP")
+}
+
+// MadviseDoDump undoes MadviseDontDump.
+func MadviseDoDump(b []byte) error {
+	return madviseNew(b, MADV_DODUMP, "MADV_DODUMP")
+}
+
+// MadviseWipeOnFork makes a forked child see the pages of b, a private
+// anonymous mapping, as zeroes, so secrets or per-process state do not leak
+// into it.
+func MadviseWipeOnFork(b []byte) error {
+	return madviseNew(b, MADV_WIPEONFORK, "MADV_WIPEONFORK")
+}
+
+// MadviseKeepOnFork undoes MadviseWipeOnFork.
+func MadviseKeepOnFork(b []byte) error {
+	return madviseNew(b, MADV_KEEPONFORK, "MADV_KEEPONFORK")
+}
+
+// MadviseCold marks the pages of b as the first to reclaim under memory
+// pressure, without reclaiming them yet.
+func MadviseCold(b []byte) error {
+	return madviseNew(b, MADV_COLD, "MADV_COLD")
+}
+
+// MadvisePageout reclaims the pages of b now, writing them to swap or back to
+// their file as needed.
+func MadvisePageout(b []byte) error {
+	return madviseNew(b, MADV_PAGEOUT, "MADV_PAGEOUT")
+}
+
+// MadvisePopulateRead faults the pages of b in, as reading them would, so
+// later reads do not stall. Unlike MAP_POPULATE it works on an existing
+// mapping and reports failure.
+func MadvisePopulateRead(b []byte) error {
+	return madviseNew(b, MADV_POPULATE_READ, "MADV_POPULATE_READ")
+}
+
+// MadvisePopulateWrite faults the pages of b in writable, as writing them
+// would, breaking copy-on-write and allocating memory now.
+func MadvisePopulateWrite(b []byte) error {
+	return madviseNew(b, MADV_POPULATE_WRITE, "MADV_POPULATE_WRITE")
+}
+
+// MadviseCollapse collapses the pages of b into transparent huge pages now,
+// rather than waiting for khugepaged. The range must be able to hold at least
+// one aligned huge page.
+func MadviseCollapse(b []byte) error {
+	return madviseNew(b, MADV_COLLAPSE, "MADV_COLLAPSE")
+}
@@ -0,0 +1,122 @@
+package posix_test
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"unsafe"
+
+	"gopkg.in/ro-ag/posix.v1"
+)
+
+// vmFlags returns the VmFlags of the mapping holding b, from /proc/self/smaps.
+func vmFlags(t *testing.T, b []byte) []string {
+	t.Helper()
+	smaps, err := os.ReadFile("/proc/self/smaps")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := uint64(uintptr(unsafe.Pointer(&b[0])))
+	found := false
+	for _, line := range strings.Split(string(smaps), "\n") {
+		var start, end uint64
+		if n, _ := fmt.Sscanf(line, "%x-%x ", &start, &end); n == 2 {
+			found = start <= addr && addr < end
+		}
+		if flags, ok := strings.CutPrefix(line, "VmFlags:"); ok && found {
+			return strings.Fields(flags)
+		}
+	}
+	t.Fatalf("no mapping holds %#x in /proc/self/smaps", addr)
+	return nil
+}
+
+func hasFlag(flags []string, flag string) bool {
+	for _, f := range flags {
+		if f == flag {
+			return true
+		}
+	}
+	return false
+}
+
+// TestMadviseAdvice applies each newer advice and checks what /proc shows of it.
+func TestMadviseAdvice(t *testing.T) {
+	pg := posix.Getpagesize()
+	b, _, err := posix.Mmap(nil, 16*pg, posix.PROT_RDWR, posix.MAP_PRIVATE|posix.MAP_ANON, -1, 0)
+	if err != nil {
+		t.Fatalf("Mmap: %v", err)
+	}
+	defer func() { _ = posix.Munmap(b) }()
+
+	// skip reports whether err says this kernel lacks the advice.
+	skip := func(name string, err error) bool {
+		if errors.Is(err, errors.ErrUnsupported) {
+			t.Logf("%s: %v", name, err)
+			return true
+		}
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			return true
+		}
+		return false
+	}
+
+	if err := posix.MadvisePopulateRead(b[:8*pg]); !skip("MadvisePopulateRead", err) {
+		if resident, _, _ := posix.Residency(b[:8*pg]); resident != 8 {
+			t.Errorf("%d pages resident after MADV_POPULATE_READ, want 8", resident)
+		}
+	}
+	if err := posix.MadvisePopulateWrite(b); !skip("MadvisePopulateWrite", err) {
+		if resident, total, _ := posix.Residency(b); resident != total {
+			t.Errorf("%d of %d pages resident after MADV_POPULATE_WRITE, want all", resident, total)
+		}
+	}
+	if err := posix.MadviseDontDump(b); !skip("MadviseDontDump", err) {
+		if !hasFlag(vmFlags(t, b), "dd") {
+			t.Error("mapping not marked dd after MADV_DONTDUMP")
+		}
+		if err := posix.MadviseDoDump(b); !skip("MadviseDoDump", err) && hasFlag(vmFlags(t, b), "dd") {
+			t.Error("mapping still marked dd after MADV_DODUMP")
+		}
+	}
+	if err := posix.MadviseWipeOnFork(b); !skip("MadviseWipeOnFork", err) {
+		if !hasFlag(vmFlags(t, b), "wf") {
+			t.Error("mapping not marked wf after MADV_WIPEONFORK")
+		}
+		if err := posix.MadviseKeepOnFork(b); !skip("MadviseKeepOnFork", err) && hasFlag(vmFlags(t, b), "wf") {
+			t.Error("mapping still marked wf after MADV_KEEPONFORK")
+		}
+	}
+	skip("MadviseCold", posix.MadviseCold(b))
+	skip("MadvisePageout", posix.MadvisePageout(b))
+	b[0] = 7
+	if err := posix.MadviseFree(b); !skip("MadviseFree", err) && b[0] != 7 && b[0] != 0 {
+		t.Errorf("page reads %d after MADV_FREE, want its contents or zero", b[0])
+	}
+	// MADV_COLLAPSE also fails when transparent huge pages are off; only
+	// ErrUnsupported says the kernel lacks it.
+	if err := posix.MadviseCollapse(b); err != nil {
+		t.Logf("MadviseCollapse: %v", err)
+	}
+}
+
+// TestMadviseEINVAL: EINVAL for reasons other than the advice is left alone.
+func TestMadviseEINVAL(t *testing.T) {
+	pg := posix.Getpagesize()
+	b, _, err := posix.Mmap(nil, 2*pg, posix.PROT_RDWR, posix.MAP_SHARED|posix.MAP_ANON, -1, 0)
+	if err != nil {
+		t.Fatalf("Mmap: %v", err)
+	}
+	defer func() { _ = posix.Munmap(b) }()
+	for name, err := range map[string]error{
+		"misaligned":       posix.MadviseDontDump(b[1:]),
+		"shared MADV_FREE": posix.MadviseFree(b),
+	} {
+		if !errors.Is(err, posix.EINVAL) || errors.Is(err, errors.ErrUnsupported) {
+			t.Errorf("%s = %v, want EINVAL and not ErrUnsupported", name, err)
+		}
+	}
+}
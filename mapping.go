@@ -0,0 +1,212 @@
+//go:build darwin || linux
+
+package posix
+
+import (
+	"io"
+	"slices"
+	"sync"
+	"unsafe"
+)
+
+// Mapping is a mapped region with file-like, bounds-checked access. It carries
+// the same bookkeeping the mapping registry keeps (the bytes, the descriptor
+// and the protection) plus the address the kernel chose and a seek offset, and
+// implements io.ReaderAt, io.WriterAt, io.ReadWriteSeeker, io.ByteReader and
+// io.Closer, so a shared region can be handed to encoding/binary, bufio or
+// io.Copy without unsafe code.
+//
+// ReadAt and WriteAt may be called concurrently, with each other and with Close
+// or the Close of the Object the Mapping came from; Read, Write, ReadByte and
+// Seek share the offset and must not be called concurrently with each other.
+// Reading needs PROT_READ and writing needs PROT_WRITE: instead of faulting,
+// they fail with EACCES.
+type Mapping struct {
+	mapping
+	mu     sync.RWMutex // held for reading across each access, for writing by Close
+	addr   uintptr
+	off    int64
+	closed bool
+}
+
+// Map maps a region exactly like Mmap and returns it as a Mapping. Release it
+// with Close.
+func Map(address unsafe.Pointer, length int, prot int, flags int, fd int, offset int64) (*Mapping, error) {
+	data, addr, err := Mmap(address, length, prot, flags, fd, offset)
+	if err != nil {
+		return nil, err
+	}
+	return &Mapping{mapping: mapping{data: data, fd: fd, prot: prot}, addr: addr}, nil
+}
+
+// Map maps part of the object as a Mapping; the arguments are those of
+// Object.Mmap, and a length of zero maps the whole object. Closing the Object
+// closes the Mapping too.
+func (o *Object) Map(address unsafe.Pointer, length int, prot int, flags int, offset int64) (*Mapping, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	data, addr, err := o.mmap(address, length, prot, flags, offset)
+	if err != nil {
+		return nil, err
+	}
+	m := &Mapping{mapping: mapping{data: data, fd: o.fd, prot: prot}, addr: addr}
+	o.maps = append(slices.DeleteFunc(o.maps, (*Mapping).isClosed), m)
+	return m, nil
+}
+
+// Addr returns the address the region is mapped at.
+func (m *Mapping) Addr() uintptr { return m.addr }
+
+// Len returns the length of the region in bytes, or 0 once it is closed.
+func (m *Mapping) Len() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.data)
+}
+
+// Prot returns the protection the region was mapped with.
+func (m *Mapping) Prot() int { return m.prot }
+
+// Fd returns the descriptor the region maps (-1 or 0 for anonymous memory, as
+// passed to Map).
+func (m *Mapping) Fd() int { return m.fd }
+
+// Bytes returns the mapped region itself. It is valid until Close.
+func (m *Mapping) Bytes() []byte {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.data
+}
+
+// ReadAt implements io.ReaderAt.
+func (m *Mapping) ReadAt(p []byte, off int64) (n int, err error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if err = m.check(off, PROT_READ); err != nil {
+		return 0, err
+	}
+	if off >= int64(len(m.data)) {
+		return 0, io.EOF
+	}
+	n = copy(p, m.data[off:])
+	if n < len(p) {
+		err = io.EOF
+	}
+	return n, err
+}
+
+// WriteAt implements io.WriterAt. A mapping cannot grow, so a write that runs
+// past the end is cut short with io.ErrShortWrite.
+func (m *Mapping) WriteAt(p []byte, off int64) (n int, err error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if err = m.check(off, PROT_WRITE); err != nil {
+		return 0, err
+	}
+	if off >= int64(len(m.data)) {
+		return 0, io.ErrShortWrite
+	}
+	n = copy(m.data[off:], p)
+	if n < len(p) {
+		err = io.ErrShortWrite
+	}
+	return n, err
+}
+
+// Read implements io.Reader, reading from the current offset.
+func (m *Mapping) Read(p []byte) (n int, err error) {
+	n, err = m.ReadAt(p, m.off)
+	m.off += int64(n)
+	if n > 0 && err == io.EOF {
+		err = nil
+	}
+	return n, err
+}
+
+// Write implements io.Writer, writing at the current offset.
+func (m *Mapping) Write(p []byte) (n int, err error) {
+	n, err = m.WriteAt(p, m.off)
+	m.off += int64(n)
+	return n, err
+}
+
+// ReadByte implements io.ByteReader.
+func (m *Mapping) ReadByte() (byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if err := m.check(m.off, PROT_READ); err != nil {
+		return 0, err
+	}
+	if m.off >= int64(len(m.data)) {
+		return 0, io.EOF
+	}
+	c := m.data[m.off]
+	m.off++
+	return c, nil
+}
+
+// Seek implements io.Seeker. Seeking past the end is allowed; reads there
+// return io.EOF. Seeking before the start returns EINVAL.
+func (m *Mapping) Seek(offset int64, whence int) (int64, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.closed {
+		return 0, ErrClosed
+	}
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += m.off
+	case io.SeekEnd:
+		offset += int64(len(m.data))
+	default:
+		return 0, EINVAL
+	}
+	if offset < 0 {
+		return 0, EINVAL
+	}
+	m.off = offset
+	return offset, nil
+}
+
+// Close unmaps the region. A second Close, or one after the Object the Mapping
+// came from was closed, returns ErrClosed.
+func (m *Mapping) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return ErrClosed
+	}
+	if err := Munmap(m.data); err != nil {
+		return err
+	}
+	m.markClosed()
+	return nil
+}
+
+// markClosed drops the region, which is unmapped or about to be. The caller
+// holds m.mu for writing.
+func (m *Mapping) markClosed() {
+	m.closed, m.data = true, nil
+}
+
+// isClosed reports whether m has been closed.
+func (m *Mapping) isClosed() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.closed
+}
+
+// check validates an access at off that needs the protection bit want. The
+// caller holds m.mu.
+func (m *Mapping) check(off int64, want int) error {
+	switch {
+	case m.closed:
+		return ErrClosed
+	case off < 0:
+		return EINVAL
+	case m.prot&want == 0:
+		return EACCES
+	}
+	return nil
+}
@@ -0,0 +1,185 @@
+//go:build darwin || linux
+
+package posix_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"testing"
+
+	"gopkg.in/ro-ag/posix.v1"
+)
+
+var (
+	_ io.ReaderAt        = (*posix.Mapping)(nil)
+	_ io.WriterAt        = (*posix.Mapping)(nil)
+	_ io.ReadWriteSeeker = (*posix.Mapping)(nil)
+	_ io.ByteReader      = (*posix.Mapping)(nil)
+	_ io.Closer          = (*posix.Mapping)(nil)
+)
+
+// TestMappingIO round-trips data through a Mapping with the standard library's
+// io consumers: encoding/binary writes through io.Writer, bufio and
+// io.ReadFull read it back, and ReadAt/WriteAt stop at the end of the region.
+func TestMappingIO(t *testing.T) {
+	pg := posix.Getpagesize()
+	fd, err := posix.MemfdCreate("mapping-io", posix.MFD_ALLOW_SEALING)
+	if err != nil {
+		t.Fatalf("MemfdCreate: %v", err)
+	}
+	defer func() { _ = posix.Close(fd) }()
+	if err := posix.Ftruncate(fd, pg); err != nil {
+		t.Fatalf("Ftruncate: %v", err)
+	}
+	m, err := posix.Map(nil, pg, posix.PROT_RDWR, posix.MAP_SHARED, fd, 0)
+	if err != nil {
+		t.Fatalf("Map: %v", err)
+	}
+	if m.Len() != pg || m.Fd() != fd || m.Prot() != posix.PROT_RDWR || m.Addr() == 0 {
+		t.Errorf("Len/Fd/Prot/Addr = %d/%d/%#x/%#x, want %d/%d/%#x/non-zero",
+			m.Len(), m.Fd(), m.Prot(), m.Addr(), pg, fd, posix.PROT_RDWR)
+	}
+
+	if err := binary.Write(m, binary.LittleEndian, [2]uint64{0xfeedface, 42}); err != nil {
+		t.Fatalf("binary.Write: %v", err)
+	}
+	if _, err := m.Seek(0, io.SeekStart); err != nil {
+		t.Fatalf("Seek: %v", err)
+	}
+	var got [2]uint64
+	if err := binary.Read(bufio.NewReader(m), binary.LittleEndian, &got); err != nil {
+		t.Fatalf("binary.Read: %v", err)
+	}
+	if got != [2]uint64{0xfeedface, 42} {
+		t.Errorf("read back %#x, want [0xfeedface 0x2a]", got)
+	}
+
+	if n, err := m.WriteAt([]byte("tail"), int64(pg-2)); n != 2 || !errors.Is(err, io.ErrShortWrite) {
+		t.Errorf("WriteAt across the end = %d, %v; want 2, io.ErrShortWrite", n, err)
+	}
+	buf := make([]byte, 4)
+	if n, err := m.ReadAt(buf, int64(pg-2)); n != 2 || err != io.EOF || !bytes.Equal(buf[:2], []byte("ta")) {
+		t.Errorf("ReadAt across the end = %d, %v, %q; want 2, EOF, \"ta\"", n, err, buf[:n])
+	}
+	if _, err := m.Seek(-1, io.SeekEnd); err != nil {
+		t.Fatalf("Seek(-1, End): %v", err)
+	}
+	if _, err := m.ReadByte(); err != nil {
+		t.Errorf("ReadByte at the last byte: %v", err)
+	}
+	if _, err := m.ReadByte(); err != io.EOF {
+		t.Errorf("ReadByte past the end = %v, want io.EOF", err)
+	}
+	if _, err := m.Seek(-1, io.SeekStart); err == nil {
+		t.Error("Seek before the start: want error, got nil")
+	}
+
+	if err := m.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if err := m.Close(); !errors.Is(err, posix.ErrClosed) {
+		t.Errorf("second Close = %v, want ErrClosed", err)
+	}
+	if _, err := m.ReadAt(buf, 0); !errors.Is(err, posix.ErrClosed) {
+		t.Errorf("ReadAt after Close = %v, want ErrClosed", err)
+	}
+}
+
+// TestMappingProtection: a read-only Mapping refuses writes with EACCES rather
+// than letting them fault.
+func TestMappingProtection(t *testing.T) {
+	m, err := posix.Map(nil, posix.Getpagesize(), posix.PROT_READ, posix.MAP_ANON|posix.MAP_PRIVATE, -1, 0)
+	if err != nil {
+		t.Fatalf("Map: %v", err)
+	}
+	defer func() { _ = m.Close() }()
+	if _, err := m.WriteAt([]byte{1}, 0); !errors.Is(err, posix.EACCES) {
+		t.Errorf("WriteAt on PROT_READ = %v, want EACCES", err)
+	}
+	if _, err := m.ReadAt(make([]byte, 1), 0); err != nil {
+		t.Errorf("ReadAt on PROT_READ: %v", err)
+	}
+}
+
+// TestObjectMapClose: closing the Object closes the Mappings it handed out, so
+// they refuse access instead of touching unmapped memory.
+func TestObjectMapClose(t *testing.T) {
+	name := fmt.Sprintf("/posix-map-close-%d", os.Getpid())
+	obj, err := posix.CreateObject(name, posix.Getpagesize(), 0o600)
+	if err != nil {
+		t.Fatalf("CreateObject: %v", err)
+	}
+	defer func() { _ = obj.Unlink() }()
+	m, err := obj.Map(nil, 0, posix.PROT_RDWR, posix.MAP_SHARED, 0)
+	if err != nil {
+		t.Fatalf("Map: %v", err)
+	}
+	if err := obj.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if _, err := m.ReadAt(make([]byte, 1), 0); !errors.Is(err, posix.ErrClosed) {
+		t.Errorf("ReadAt after the Object closed = %v, want ErrClosed", err)
+	}
+	if _, err := m.WriteAt([]byte{1}, 0); !errors.Is(err, posix.ErrClosed) {
+		t.Errorf("WriteAt after the Object closed = %v, want ErrClosed", err)
+	}
+	if err := m.Close(); !errors.Is(err, posix.ErrClosed) {
+		t.Errorf("Close after the Object closed = %v, want ErrClosed", err)
+	}
+}
+
+// TestObjectMapCloseRace accesses Mappings while they and their Object are
+// closed: every access either completes or fails with ErrClosed, and none
+// touches memory that is already unmapped.
+func TestObjectMapCloseRace(t *testing.T) {
+	name := fmt.Sprintf("/posix-map-race-%d", os.Getpid())
+	obj, err := posix.CreateObject(name, posix.Getpagesize(), 0o600)
+	if err != nil {
+		t.Fatalf("CreateObject: %v", err)
+	}
+	defer func() { _ = obj.Unlink() }()
+
+	var wg sync.WaitGroup
+	for i := range 4 {
+		m, err := obj.Map(nil, 0, posix.PROT_RDWR, posix.MAP_SHARED, 0)
+		if err != nil {
+			t.Fatalf("Map: %v", err)
+		}
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for {
+				if _, err := m.WriteAt([]byte{byte(i)}, 0); err != nil {
+					if !errors.Is(err, posix.ErrClosed) {
+						t.Errorf("WriteAt = %v, want nil or ErrClosed", err)
+					}
+					return
+				}
+				if _, err := m.ReadAt(make([]byte, 1), 0); err != nil {
+					if !errors.Is(err, posix.ErrClosed) {
+						t.Errorf("ReadAt = %v, want nil or ErrClosed", err)
+					}
+					return
+				}
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			if i%2 == 0 {
+				if err := m.Close(); err != nil && !errors.Is(err, posix.ErrClosed) {
+					t.Errorf("Mapping Close = %v, want nil or ErrClosed", err)
+				}
+			}
+		}()
+	}
+	if err := obj.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	wg.Wait()
+}
@@ -9,6 +9,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"sync"
+	"syscall"
 	"testing"
 
 	"gopkg.in/ro-ag/posix.v1"
@@ -125,7 +126,14 @@ func TestMemfdFdPassing(t *testing.T) {
 	}
 	defer func() { _ = posix.Munmap(buf) }()
 
-	f := os.NewFile(uintptr(fd), "memfd")
+	// The child gets a dup owned by an *os.File, so the File's finalizer
+	// never closes fd, or whatever later reuses its number.
+	dup, err := syscall.Dup(fd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := os.NewFile(uintptr(dup), "memfd")
+	defer func() { _ = f.Close() }()
 	cmd := exec.Command(bin)
 	cmd.ExtraFiles = []*os.File{f} // child sees this as fd 3
 	var stderr bytes.Buffer
@@ -0,0 +1,36 @@
+package posix
+
+import "unsafe"
+
+// Mincore reports which pages of b are resident in memory: one byte per page,
+// for every page b touches, with bit 0 set if the page is resident. It is how
+// to check that MADV_WILLNEED or MAP_POPULATE did their job. b must lie in
+// mapped memory, or Mincore fails with ENOMEM. The answer is a snapshot: pages
+// may be evicted or faulted in right after.
+func Mincore(b []byte) ([]byte, error) {
+	if len(b) == 0 {
+		return nil, EINVAL
+	}
+	pg := uintptr(Getpagesize())
+	start := uintptr(unsafe.Pointer(&b[0]))
+	first := start &^ (pg - 1)
+	length := start + uintptr(len(b)) - first
+	vec := make([]byte, (length+pg-1)/pg)
+	if err := mincore(first, length, vec); err != nil {
+		return nil, err
+	}
+	return vec, nil
+}
+
+// Residency returns how many of the pages b touches are resident in memory,
+// out of total.
+func Residency(b []byte) (resident, total int, err error) {
+	vec, err := Mincore(b)
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, v := range vec {
+		resident += int(v & 1)
+	}
+	return resident, len(vec), nil
+}
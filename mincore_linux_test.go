@@ -0,0 +1,56 @@
+package posix_test
+
+import (
+	"errors"
+	"testing"
+
+	"gopkg.in/ro-ag/posix.v1"
+)
+
+func TestResidency(t *testing.T) {
+	pg := posix.Getpagesize()
+	b, _, err := posix.Mmap(nil, 16*pg, posix.PROT_RDWR, posix.MAP_PRIVATE|posix.MAP_ANON, -1, 0)
+	if err != nil {
+		t.Fatalf("Mmap: %v", err)
+	}
+	defer func() { _ = posix.Munmap(b) }()
+
+	if resident, total, err := posix.Residency(b); err != nil || resident != 0 || total != 16 {
+		t.Errorf("Residency of untouched memory = %d/%d, %v, want 0/16", resident, total, err)
+	}
+	for _, p := range []int{0, 5, 15} {
+		b[p*pg] = 1
+	}
+	vec, err := posix.Mincore(b)
+	if err != nil {
+		t.Fatalf("Mincore: %v", err)
+	}
+	for p, v := range vec {
+		if want := p == 0 || p == 5 || p == 15; (v&1 != 0) != want {
+			t.Errorf("page %d resident = %v, want %v", p, v&1 != 0, want)
+		}
+	}
+	// A slice not starting on a page boundary covers every page it touches.
+	if resident, total, err := posix.Residency(b[5*pg-1 : 5*pg+1]); err != nil || resident != 1 || total != 2 {
+		t.Errorf("Residency across pages 4 and 5 = %d/%d, %v, want 1/2", resident, total, err)
+	}
+	if err := posix.Madvise(b, posix.MADV_DONTNEED); err != nil {
+		t.Fatalf("Madvise: %v", err)
+	}
+	if resident, _, err := posix.Residency(b); err != nil || resident != 0 {
+		t.Errorf("Residency after MADV_DONTNEED = %d, %v, want 0", resident, err)
+	}
+
+	// MAP_POPULATE faults everything in up front.
+	p, _, err := posix.Mmap(nil, 16*pg, posix.PROT_RDWR, posix.MAP_PRIVATE|posix.MAP_ANON|posix.MAP_POPULATE, -1, 0)
+	if err != nil {
+		t.Fatalf("Mmap: %v", err)
+	}
+	if resident, total, err := posix.Residency(p); err != nil || resident != total {
+		t.Errorf("Residency with MAP_POPULATE = %d/%d, %v, want all", resident, total, err)
+	}
+	_ = posix.Munmap(p)
+	if _, _, err := posix.Residency(p); !errors.Is(err, posix.ENOMEM) {
+		t.Errorf("Residency of unmapped memory = %v, want ENOMEM", err)
+	}
+}
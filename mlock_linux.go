@@ -0,0 +1,103 @@
+package posix
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"syscall"
+	"unsafe"
+)
+
+//goland:noinspection GoSnakeCaseUsage
+const (
+	MLOCK_ONFAULT = 0x1 // Mlock2: lock pages as they are faulted in, not all up front (Linux 4.4+)
+	MCL_ONFAULT   = 0x4 // Mlockall, with MCL_CURRENT or MCL_FUTURE: lock pages as they are faulted in
+
+	RLIMIT_MEMLOCK = 0x8 // the resource limit on locked memory, in bytes
+)
+
+// ErrMemlockLimit is returned by Mlock2 when locking a region would take the
+// process over its RLIMIT_MEMLOCK. It matches ENOMEM too, which is what the
+// kernel would have returned.
+var ErrMemlockLimit = errors.New("posix: RLIMIT_MEMLOCK exceeded")
+
+// MemlockStatus is a process's locked-memory accounting.
+type MemlockStatus struct {
+	Limit  uint64 // soft RLIMIT_MEMLOCK in bytes; math.MaxUint64 for no limit
+	Max    uint64 // hard RLIMIT_MEMLOCK in bytes
+	Locked uint64 // bytes locked now: VmLck in /proc/thread-self/status
+	Exempt bool   // the thread has CAP_IPC_LOCK, so the limit does not apply
+}
+
+// capIPCLock is the bit of CAP_IPC_LOCK in the capability sets.
+const capIPCLock = 14
+
+// Memlock returns how much memory the process has locked and how much it may
+// lock. Capabilities belong to threads; Exempt is that of the calling one.
+func Memlock() (MemlockStatus, error) {
+	var st MemlockStatus
+	var lim syscall.Rlimit
+	if err := syscall.Getrlimit(RLIMIT_MEMLOCK, &lim); err != nil {
+		return st, err
+	}
+	st.Limit, st.Max = lim.Cur, lim.Max
+
+	status, err := os.ReadFile("/proc/thread-self/status")
+	if err != nil {
+		return st, err
+	}
+	sc := bufio.NewScanner(bytes.NewReader(status))
+	for sc.Scan() {
+		key, value, _ := bytes.Cut(sc.Bytes(), []byte(":"))
+		value = bytes.TrimSpace(value)
+		switch string(key) {
+		case "VmLck":
+			kb, err := strconv.ParseUint(string(bytes.TrimSuffix(value, []byte(" kB"))), 10, 64)
+			if err != nil {
+				return st, fmt.Errorf("posix: parsing VmLck: %w", err)
+			}
+			st.Locked = kb * 1024
+		case "CapEff":
+			caps, err := strconv.ParseUint(string(value), 16, 64)
+			if err != nil {
+				return st, fmt.Errorf("posix: parsing CapEff: %w", err)
+			}
+			st.Exempt = caps&(1<<capIPCLock) != 0
+		}
+	}
+	return st, nil
+}
+
+// Mlock2 locks the pages b covers into memory, as Mlock does, but with flags:
+// MLOCK_ONFAULT locks each page as it is first touched instead of faulting
+// the whole region in now. If the lock would take the process over its
+// RLIMIT_MEMLOCK, Mlock2 fails up front with an ErrMemlockLimit that says by
+// how much, rather than the kernel's bare ENOMEM.
+func Mlock2(b []byte, flags int) error {
+	if err := memlockCheck(b); err != nil {
+		return err
+	}
+	return mlock2(b, flags)
+}
+
+// memlockCheck does the kernel's RLIMIT_MEMLOCK check before it does. Pages of
+// b that are locked already count twice, so it may refuse what the kernel
+// would allow; the kernel has the last word on everything it lets through.
+func memlockCheck(b []byte) error {
+	st, err := Memlock()
+	if err != nil || st.Exempt || st.Limit == math.MaxUint64 || len(b) == 0 {
+		return nil
+	}
+	pg := uint64(Getpagesize())
+	start := uint64(uintptr(unsafe.Pointer(&b[0])))
+	need := (start+uint64(len(b))+pg-1)&^(pg-1) - start&^(pg-1)
+	if st.Locked+need > st.Limit {
+		return fmt.Errorf("%w: locking %d bytes with %d locked already would exceed the limit of %d (%w)",
+			ErrMemlockLimit, need, st.Locked, st.Limit, ENOMEM)
+	}
+	return nil
+}
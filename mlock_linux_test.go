@@ -0,0 +1,134 @@
+package posix_test
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"syscall"
+	"testing"
+	"unsafe"
+
+	"gopkg.in/ro-ag/posix.v1"
+)
+
+func TestMlock2OnFault(t *testing.T) {
+	pg := posix.Getpagesize()
+	b, _, err := posix.Mmap(nil, 16*pg, posix.PROT_RDWR, posix.MAP_PRIVATE|posix.MAP_ANON, -1, 0)
+	if err != nil {
+		t.Fatalf("Mmap: %v", err)
+	}
+	defer func() { _ = posix.Munmap(b) }()
+	before, err := posix.Memlock()
+	if err != nil {
+		t.Fatalf("Memlock: %v", err)
+	}
+	if before.Limit > before.Max {
+		t.Errorf("Memlock = %+v, soft limit above the hard one", before)
+	}
+
+	if err := posix.Mlock2(b, posix.MLOCK_ONFAULT); err != nil {
+		t.Skipf("Mlock2 unavailable: %v", err)
+	}
+	defer func() { _ = posix.Munlock(b, len(b)) }()
+	if resident, _, _ := posix.Residency(b); resident != 0 {
+		t.Errorf("%d pages resident after MLOCK_ONFAULT, want none until touched", resident)
+	}
+	b[3*pg] = 1
+	if resident, _, _ := posix.Residency(b); resident != 1 {
+		t.Errorf("%d pages resident after touching one, want 1", resident)
+	}
+	after, err := posix.Memlock()
+	if err != nil {
+		t.Fatalf("Memlock: %v", err)
+	}
+	if after.Locked < before.Locked+uint64(len(b)) {
+		t.Errorf("VmLck went from %d to %d, want it up by the %d bytes locked", before.Locked, after.Locked, len(b))
+	}
+
+	if err := posix.Munlock(b, len(b)); err != nil {
+		t.Fatalf("Munlock: %v", err)
+	}
+	if err := posix.Mlock2(b, 0); err != nil {
+		t.Fatalf("Mlock2: %v", err)
+	}
+	if resident, total, _ := posix.Residency(b); resident != total {
+		t.Errorf("%d of %d pages resident after Mlock2 without MLOCK_ONFAULT, want all", resident, total)
+	}
+}
+
+// capabilities is the capget/capset argument pair, version 3.
+type capabilities struct {
+	hdr  struct{ version, pid uint32 }
+	data [2]struct{ effective, permitted, inheritable uint32 }
+}
+
+func (c *capabilities) call(trap uintptr) error {
+	c.hdr.version = 0x20080522
+	_, _, e := syscall.RawSyscall(trap, uintptr(unsafe.Pointer(&c.hdr)), uintptr(unsafe.Pointer(&c.data[0])), 0)
+	if e != 0 {
+		return e
+	}
+	return nil
+}
+
+// TestMlock2Limit locks more than RLIMIT_MEMLOCK allows, on a thread without
+// CAP_IPC_LOCK, and expects the descriptive error rather than the syscall.
+func TestMlock2Limit(t *testing.T) {
+	pg := posix.Getpagesize()
+	b, _, err := posix.Mmap(nil, 16*pg, posix.PROT_RDWR, posix.MAP_PRIVATE|posix.MAP_ANON, -1, 0)
+	if err != nil {
+		t.Fatalf("Mmap: %v", err)
+	}
+	defer func() { _ = posix.Munmap(b) }()
+	var old syscall.Rlimit
+	if err := syscall.Getrlimit(posix.RLIMIT_MEMLOCK, &old); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = syscall.Setrlimit(posix.RLIMIT_MEMLOCK, &old) }()
+
+	// Mlockall by an earlier test may leave too much locked to set a limit
+	// just above it; that is reported as errSkip.
+	errSkip := errors.New("cannot lower RLIMIT_MEMLOCK")
+	done := make(chan error, 1)
+	go func() {
+		// Never unlocked: the thread, with its reduced capabilities, exits
+		// with the goroutine.
+		runtime.LockOSThread()
+		var caps capabilities
+		if err := caps.call(syscall.SYS_CAPGET); err != nil {
+			done <- err
+			return
+		}
+		caps.data[0].effective &^= 1 << 14 // CAP_IPC_LOCK
+		if err := caps.call(syscall.SYS_CAPSET); err != nil {
+			done <- err
+			return
+		}
+		st, err := posix.Memlock()
+		if err != nil {
+			done <- err
+			return
+		}
+		if st.Exempt {
+			t.Error("Memlock reports CAP_IPC_LOCK after it was dropped")
+		}
+		lim := syscall.Rlimit{Cur: st.Locked + uint64(4*pg), Max: old.Max}
+		if lim.Cur > lim.Max {
+			done <- fmt.Errorf("%w: %d bytes locked already, hard limit %d", errSkip, st.Locked, lim.Max)
+			return
+		}
+		if err := syscall.Setrlimit(posix.RLIMIT_MEMLOCK, &lim); err != nil {
+			done <- fmt.Errorf("%w: %v", errSkip, err)
+			return
+		}
+		done <- posix.Mlock2(b, posix.MLOCK_ONFAULT)
+	}()
+	err = <-done
+	if errors.Is(err, errSkip) {
+		t.Skip(err)
+	}
+	if !errors.Is(err, posix.ErrMemlockLimit) || !errors.Is(err, posix.ENOMEM) {
+		t.Fatalf("Mlock2 over RLIMIT_MEMLOCK = %v, want ErrMemlockLimit and ENOMEM", err)
+	}
+	t.Log(err)
+}
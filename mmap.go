@@ -3,6 +3,7 @@
 package posix
 
 import (
+	"errors"
 	"sync"
 	"unsafe"
 )
@@ -11,10 +12,21 @@ import (
 // and protection it was created with. The fd/prot are used by the seal logic
 // (hasWritableMapping) to mirror the kernel's "F_SEAL_WRITE needs no live
 // writable mapping" rule.
+//
+// owner is the Object whose Mmap made the mapping, if any: Object.Close
+// releases those and no others, whatever descriptor they were made from.
+//
+// A Reservation is one entry whose commits hold the fixed mappings laid over
+// it, keyed by their offset into the reservation; commits is non-nil exactly
+// for a Reservation. They are not entries of their own: they may share the
+// reservation's base byte, and are released with it. A mapping moved into the
+// reservation (MremapFixed, Shmat with SHM_REMAP) becomes one of its commits.
 type mapping struct {
-	data []byte
-	fd   int
-	prot int
+	data    []byte
+	fd      int
+	prot    int
+	commits map[uintptr]mapping
+	owner   *Object
 }
 
 // mmapper tracks active mappings so Munmap can recover each mapping's base
@@ -52,6 +64,12 @@ func (m *mmapper) Mmap(address unsafe.Pointer, length uintptr, prot int, flags i
 }
 
 func (m *mmapper) Munmap(data []byte) (err error) {
+	return m.release(data, m.munmap)
+}
+
+// release undoes the mapping data with undo, munmap or its equivalent for the
+// kind of mapping, and drops its bookkeeping entry.
+func (m *mmapper) release(data []byte, undo func(addr uintptr, length uintptr) error) (err error) {
 	if len(data) == 0 || len(data) != cap(data) {
 		return EINVAL
 	}
@@ -66,11 +84,17 @@ func (m *mmapper) Munmap(data []byte) (err error) {
 		return EINVAL
 	}
 
-	// Unmap the memory and drop the bookkeeping entry.
-	if errno := m.munmap(uintptr(unsafe.Pointer(&mp.data[0])), uintptr(len(mp.data))); errno != nil {
+	// Unmap the memory and drop the bookkeeping entry. A Reservation's range
+	// may also hold mappings laid over it with plain MAP_FIXED, and those went
+	// with it.
+	base, length := uintptr(unsafe.Pointer(&mp.data[0])), uintptr(len(mp.data))
+	if errno := undo(base, length); errno != nil {
 		return errno
 	}
 	delete(m.active, p)
+	if mp.commits != nil {
+		m.forget(base, length)
+	}
 	return nil
 }
 
@@ -84,10 +108,89 @@ func (m *mmapper) hasWritableMapping(fd int) bool {
 		if mp.fd == fd && mp.prot&PROT_WRITE != 0 {
 			return true
 		}
+		for _, c := range mp.commits {
+			if c.fd == fd && c.prot&PROT_WRITE != 0 {
+				return true
+			}
+		}
 	}
 	return false
 }
 
+// own marks the mapping data as made through o.
+func (m *mmapper) own(data []byte, o *Object) {
+	m.Lock()
+	defer m.Unlock()
+	if mp, ok := m.active[&data[0]]; ok {
+		mp.owner = o
+		m.active[&data[0]] = mp
+	}
+}
+
+// munmapOwner unmaps every live mapping made through o. Object uses it on Close
+// so a handle never leaves mappings behind once its descriptor is gone. It goes
+// by owner, not descriptor number: a mapping of another descriptor that has
+// since reused the number is not the Object's to release. All mappings are
+// attempted; the errors of any that fail are joined.
+func (m *mmapper) munmapOwner(o *Object) error {
+	m.Lock()
+	defer m.Unlock()
+	var errs []error
+	for p, mp := range m.active {
+		if mp.owner != o {
+			continue
+		}
+		if err := m.munmap(uintptr(unsafe.Pointer(&mp.data[0])), uintptr(len(mp.data))); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		delete(m.active, p)
+	}
+	return errors.Join(errs...)
+}
+
+// forget drops the bookkeeping of everything inside [addr, addr+length)
+// without unmapping anything; it is for ranges the kernel has already
+// replaced. Entries wholly inside the range go, as do the commits of a
+// Reservation that the range overlaps. The Reservation itself, and any other
+// entry the range only partly covers, stay registered so that they can still
+// be released. The caller holds the lock.
+func (m *mmapper) forget(addr, length uintptr) {
+	for p, mp := range m.active {
+		base := uintptr(unsafe.Pointer(p))
+		end := base + uintptr(len(mp.data))
+		if mp.commits == nil && addr <= base && end <= addr+length {
+			delete(m.active, p)
+			continue
+		}
+		for o, c := range mp.commits {
+			if base+o < addr+length && addr < base+o+uintptr(len(c.data)) {
+				delete(mp.commits, o)
+			}
+		}
+	}
+}
+
+// place registers mp, whose memory the kernel has just put at a fixed address,
+// after forget has dropped what it replaced. Inside a Reservation it becomes a
+// commit, keyed by its offset as Commit keys them, so that Munmap refuses it
+// and Decommit and Release give it back; anywhere else it is an entry of its
+// own. The caller holds the lock.
+func (m *mmapper) place(mp mapping) {
+	addr := uintptr(unsafe.Pointer(&mp.data[0]))
+	for _, r := range m.active {
+		if r.commits == nil {
+			continue
+		}
+		base := uintptr(unsafe.Pointer(&r.data[0]))
+		if base <= addr && addr+uintptr(len(mp.data)) <= base+uintptr(len(r.data)) {
+			r.commits[addr-base] = mapping{data: mp.data, fd: mp.fd, prot: mp.prot}
+			return
+		}
+	}
+	m.active[&mp.data[0]] = mp
+}
+
 var mapper = &mmapper{
 	active: make(map[*byte]mapping),
 	mmap:   mmap,
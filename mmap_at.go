@@ -0,0 +1,40 @@
+//go:build darwin || linux
+
+package posix
+
+import (
+	"errors"
+	"fmt"
+	"unsafe"
+)
+
+// ErrAddressInUse is returned by MmapAt when part of the requested range is
+// already mapped — by the Go heap, a goroutine stack, or another mapping.
+var ErrAddressInUse = errors.New("posix: address range already in use")
+
+// MmapAt maps length bytes at exactly address, like Mmap with MAP_FIXED, but
+// without MAP_FIXED's hazard of silently replacing whatever already lives
+// there. If any of the range is taken, nothing is mapped and the error wraps
+// ErrAddressInUse.
+//
+// On Linux it uses MAP_FIXED_NOREPLACE. Kernels before 4.17 and macOS treat the
+// address only as a hint, so the result is checked and a mapping the kernel
+// placed elsewhere is undone. address must be non-nil and page-aligned, and
+// flags must not include MAP_FIXED.
+func MmapAt(address unsafe.Pointer, length int, prot int, flags int, fd int, offset int64) ([]byte, error) {
+	if address == nil || uintptr(address)%uintptr(Getpagesize()) != 0 || flags&MAP_FIXED != 0 {
+		return nil, EINVAL
+	}
+	data, addr, err := Mmap(address, length, prot, flags|mapNoReplace, fd, offset)
+	if err == EEXIST {
+		return nil, fmt.Errorf("%w: %#x+%#x", ErrAddressInUse, uintptr(address), length)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if addr != uintptr(address) {
+		_ = Munmap(data)
+		return nil, fmt.Errorf("%w: %#x+%#x (kernel offered %#x)", ErrAddressInUse, uintptr(address), length, addr)
+	}
+	return data, nil
+}
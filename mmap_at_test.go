@@ -0,0 +1,69 @@
+//go:build darwin || linux
+
+package posix_test
+
+import (
+	"errors"
+	"testing"
+	"unsafe"
+
+	"gopkg.in/ro-ag/posix.v1"
+)
+
+// TestMmapAt: a free address is honored exactly, and an address that is
+// already mapped is refused with ErrAddressInUse while the existing mapping's
+// contents stay intact — the clobbering MAP_FIXED would have done.
+func TestMmapAt(t *testing.T) {
+	const want = 0x32000000000 // distinct from the addresses other tests use
+	pg := posix.Getpagesize()
+
+	buf, err := posix.MmapAt(unsafe.Pointer(uintptr(want)), 2*pg, posix.PROT_RDWR, posix.MAP_ANON|posix.MAP_PRIVATE, -1, 0)
+	if err != nil {
+		t.Fatalf("MmapAt on a free range: %v", err)
+	}
+	defer func() { _ = posix.Munmap(buf) }()
+	if got := uintptr(unsafe.Pointer(&buf[0])); got != want {
+		t.Fatalf("MmapAt placed the mapping at %#x, want %#x", got, uintptr(want))
+	}
+	buf[pg] = 0x7e
+
+	// Overlap the second page of the live mapping.
+	_, err = posix.MmapAt(unsafe.Add(unsafe.Pointer(&buf[0]), pg), pg, posix.PROT_RDWR, posix.MAP_ANON|posix.MAP_PRIVATE, -1, 0)
+	if !errors.Is(err, posix.ErrAddressInUse) {
+		t.Fatalf("MmapAt over a live mapping = %v, want ErrAddressInUse", err)
+	}
+	if buf[pg] != 0x7e {
+		t.Errorf("existing mapping was clobbered: byte = %#x, want 0x7e", buf[pg])
+	}
+}
+
+// TestMmapAtGoHeap: the Go heap is just another mapping; MmapAt must refuse it.
+func TestMmapAtGoHeap(t *testing.T) {
+	pg := posix.Getpagesize()
+	heap := make([]byte, 4*pg)
+	base := uintptr(unsafe.Pointer(&heap[0]))
+	addr := unsafe.Add(unsafe.Pointer(&heap[0]), (base+uintptr(pg)-1)&^(uintptr(pg)-1)-base)
+	_, err := posix.MmapAt(addr, pg, posix.PROT_RDWR, posix.MAP_ANON|posix.MAP_PRIVATE, -1, 0)
+	if !errors.Is(err, posix.ErrAddressInUse) {
+		t.Errorf("MmapAt over the Go heap = %v, want ErrAddressInUse", err)
+	}
+}
+
+// TestMmapAtArguments: MmapAt needs a page-aligned address and refuses
+// MAP_FIXED, which would defeat its purpose.
+func TestMmapAtArguments(t *testing.T) {
+	pg := posix.Getpagesize()
+	for _, c := range []struct {
+		name  string
+		addr  uintptr
+		flags int
+	}{
+		{"nil address", 0, posix.MAP_ANON | posix.MAP_PRIVATE},
+		{"unaligned address", 0x33000000001, posix.MAP_ANON | posix.MAP_PRIVATE},
+		{"MAP_FIXED", 0x33000000000, posix.MAP_ANON | posix.MAP_PRIVATE | posix.MAP_FIXED},
+	} {
+		if _, err := posix.MmapAt(unsafe.Pointer(c.addr), pg, posix.PROT_RDWR, c.flags, -1, 0); !errors.Is(err, posix.EINVAL) {
+			t.Errorf("%s: err = %v, want EINVAL", c.name, err)
+		}
+	}
+}
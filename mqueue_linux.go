@@ -0,0 +1,109 @@
+package posix
+
+import "time"
+
+// MqAttr is struct mq_attr: the attributes of a message queue.
+type MqAttr struct {
+	Flags   int64 // 0 or O_NONBLOCK; the only field MqSetAttr changes
+	Maxmsg  int64 // maximum number of messages on the queue
+	Msgsize int64 // maximum size of a message, in bytes
+	Curmsgs int64 // number of messages currently on the queue
+	_       [4]int64
+}
+
+// Sigevent is struct sigevent, which tells MqNotify how to announce a message.
+// Value is delivered with the signal, in si_value.
+type Sigevent struct {
+	Value  uintptr
+	Signo  int32
+	Notify int32 // SIGEV_SIGNAL or SIGEV_NONE
+	_      [48]byte
+}
+
+//goland:noinspection GoSnakeCaseUsage
+const (
+	SIGEV_SIGNAL = 0 // send Signo
+	SIGEV_NONE   = 1 // just take the registration: the queue is claimed, but nothing is sent
+)
+
+// MqOpen opens the message queue name, the way mq_open does, and returns its
+// descriptor, which is close-on-exec and closed with Close. oflag is O_RDONLY,
+// O_WRONLY or O_RDWR, plus O_CREAT, O_EXCL and O_NONBLOCK. When the queue is
+// created, attr, if not nil, sets its Maxmsg and Msgsize; otherwise the system
+// defaults apply. Names follow the ShmOpen rules.
+func MqOpen(name string, oflag int, mode uint32, attr *MqAttr) (int, error) {
+	name, err := ipcName(name, 0)
+	if err != nil {
+		return -1, err
+	}
+	return mqOpen(name, oflag, mode, attr)
+}
+
+// MqUnlink removes the message queue name. Processes that have it open keep
+// using it.
+func MqUnlink(name string) error {
+	name, err := ipcName(name, 0)
+	if err != nil {
+		return err
+	}
+	return mqUnlink(name)
+}
+
+// MqSend adds msg to the queue with priority prio, waiting for room if the
+// queue is full, unless it was opened with O_NONBLOCK, in which case it fails
+// with EAGAIN.
+func MqSend(mqd int, msg []byte, prio uint) error {
+	return mqTimedsend(mqd, msg, prio, nil)
+}
+
+// MqTimedSend is MqSend with an absolute deadline on the realtime clock, as
+// in mq_timedsend. It fails with ETIMEDOUT if the queue is still full then.
+func MqTimedSend(mqd int, msg []byte, prio uint, deadline time.Time) error {
+	return mqTimedsend(mqd, msg, prio, deadlineTimespec(deadline))
+}
+
+// MqReceive removes the oldest message of the highest priority from the
+// queue, copies it into buf and returns its length and priority. It waits for
+// a message if the queue is empty, unless it was opened with O_NONBLOCK, in
+// which case it fails with EAGAIN. buf must hold at least Msgsize bytes, or
+// MqReceive fails with EMSGSIZE.
+func MqReceive(mqd int, buf []byte) (n int, prio uint, err error) {
+	var p uint32
+	n, err = mqTimedreceive(mqd, buf, &p, nil)
+	return n, uint(p), err
+}
+
+// MqTimedReceive is MqReceive with an absolute deadline on the realtime clock,
+// as in mq_timedreceive. It fails with ETIMEDOUT if the queue is still empty
+// then.
+func MqTimedReceive(mqd int, buf []byte, deadline time.Time) (n int, prio uint, err error) {
+	var p uint32
+	n, err = mqTimedreceive(mqd, buf, &p, deadlineTimespec(deadline))
+	return n, uint(p), err
+}
+
+// MqGetAttr returns the attributes of the queue.
+func MqGetAttr(mqd int) (MqAttr, error) {
+	var attr MqAttr
+	err := mqGetsetattr(mqd, nil, &attr)
+	return attr, err
+}
+
+// MqSetAttr sets the O_NONBLOCK flag of mqd from attr.Flags, ignoring the rest
+// of attr, and returns the attributes as they were before.
+func MqSetAttr(mqd int, attr *MqAttr) (MqAttr, error) {
+	var old MqAttr
+	err := mqGetsetattr(mqd, attr, &old)
+	return old, err
+}
+
+// MqNotify asks for a notification, described by sev, when a message arrives
+// on the empty queue and no one is waiting in MqReceive for it. Only one
+// process can be registered at a time, and the registration is removed once
+// the notification is sent. A nil sev removes this process's registration.
+//
+// With SIGEV_SIGNAL, receive the signal with os/signal; SIGEV_THREAD is
+// implemented by the C library and is not available.
+func MqNotify(mqd int, sev *Sigevent) error {
+	return mqNotify(mqd, sev)
+}
@@ -0,0 +1,205 @@
+package posix_test
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"gopkg.in/ro-ag/posix.v1"
+)
+
+// mqOpen creates a message queue unique to this test, of 4 messages of up to
+// 64 bytes, and removes it when the test ends.
+func mqOpen(t *testing.T, oflag int) (string, int) {
+	t.Helper()
+	name := fmt.Sprintf("/posix-test-%s-%d", t.Name(), os.Getpid())
+	attr := posix.MqAttr{Maxmsg: 4, Msgsize: 64}
+	mqd, err := posix.MqOpen(name, posix.O_RDWR|posix.O_CREAT|posix.O_EXCL|oflag, 0o600, &attr)
+	if err != nil {
+		t.Fatalf("MqOpen: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = posix.Close(mqd)
+		_ = posix.MqUnlink(name)
+	})
+	return name, mqd
+}
+
+func TestMq(t *testing.T) {
+	name, mqd := mqOpen(t, 0)
+	if _, err := posix.MqOpen(name, posix.O_RDWR|posix.O_CREAT|posix.O_EXCL, 0o600, nil); !errors.Is(err, posix.EEXIST) {
+		t.Errorf("second exclusive MqOpen = %v, want EEXIST", err)
+	}
+	if _, err := posix.MqOpen("/a/b", posix.O_RDWR, 0, nil); !errors.Is(err, posix.EINVAL) {
+		t.Errorf("MqOpen with a slash in the name = %v, want EINVAL", err)
+	}
+
+	for i, prio := range []uint{1, 5, 1} {
+		if err := posix.MqSend(mqd, []byte{byte(i)}, prio); err != nil {
+			t.Fatalf("MqSend: %v", err)
+		}
+	}
+	attr, err := posix.MqGetAttr(mqd)
+	if err != nil {
+		t.Fatalf("MqGetAttr: %v", err)
+	}
+	if attr.Maxmsg != 4 || attr.Msgsize != 64 || attr.Curmsgs != 3 || attr.Flags != 0 {
+		t.Errorf("MqGetAttr = %+v, want 4 messages of 64 bytes, 3 queued", attr)
+	}
+
+	buf := make([]byte, 65)
+	if _, _, err := posix.MqReceive(mqd, buf[:8]); !errors.Is(err, syscall.EMSGSIZE) {
+		t.Errorf("MqReceive into a short buffer = %v, want EMSGSIZE", err)
+	}
+	// Highest priority first, then oldest first.
+	for _, want := range []struct {
+		msg  byte
+		prio uint
+	}{{1, 5}, {0, 1}, {2, 1}} {
+		n, prio, err := posix.MqTimedReceive(mqd, buf, time.Now().Add(time.Second))
+		if err != nil {
+			t.Fatalf("MqTimedReceive: %v", err)
+		}
+		if n != 1 || buf[0] != want.msg || prio != want.prio {
+			t.Errorf("MqTimedReceive = %v prio %d, want [%d] prio %d", buf[:n], prio, want.msg, want.prio)
+		}
+	}
+
+	start := time.Now()
+	if _, _, err := posix.MqTimedReceive(mqd, buf, start.Add(20*time.Millisecond)); !errors.Is(err, posix.ETIMEDOUT) {
+		t.Errorf("MqTimedReceive on an empty queue = %v, want ETIMEDOUT", err)
+	}
+	if d := time.Since(start); d < 20*time.Millisecond {
+		t.Errorf("MqTimedReceive returned after %v, before its deadline", d)
+	}
+	for range 4 {
+		if err := posix.MqSend(mqd, buf[:64], 0); err != nil {
+			t.Fatalf("MqSend: %v", err)
+		}
+	}
+	if err := posix.MqTimedSend(mqd, buf[:1], 0, time.Now().Add(10*time.Millisecond)); !errors.Is(err, posix.ETIMEDOUT) {
+		t.Errorf("MqTimedSend to a full queue = %v, want ETIMEDOUT", err)
+	}
+	if err := posix.MqSend(mqd, buf[:65], 0); !errors.Is(err, syscall.EMSGSIZE) {
+		t.Errorf("MqSend of an oversized message = %v, want EMSGSIZE", err)
+	}
+
+	old, err := posix.MqSetAttr(mqd, &posix.MqAttr{Flags: posix.O_NONBLOCK})
+	if err != nil {
+		t.Fatalf("MqSetAttr: %v", err)
+	}
+	if old.Flags != 0 || old.Curmsgs != 4 {
+		t.Errorf("MqSetAttr returned %+v, want the blocking queue with 4 messages", old)
+	}
+	if err := posix.MqSend(mqd, buf[:1], 0); !errors.Is(err, posix.EAGAIN) {
+		t.Errorf("nonblocking MqSend to a full queue = %v, want EAGAIN", err)
+	}
+	for range 4 {
+		if _, _, err := posix.MqReceive(mqd, buf); err != nil {
+			t.Fatalf("MqReceive: %v", err)
+		}
+	}
+	if _, _, err := posix.MqReceive(mqd, buf); !errors.Is(err, posix.EAGAIN) {
+		t.Errorf("nonblocking MqReceive on an empty queue = %v, want EAGAIN", err)
+	}
+}
+
+// TestMqNotify: a message arriving on an empty queue raises the signal asked
+// for, once.
+func TestMqNotify(t *testing.T) {
+	_, mqd := mqOpen(t, posix.O_NONBLOCK)
+	sig := make(chan os.Signal, 2)
+	signal.Notify(sig, syscall.SIGUSR1)
+	defer signal.Stop(sig)
+
+	sev := posix.Sigevent{Notify: posix.SIGEV_SIGNAL, Signo: int32(syscall.SIGUSR1)}
+	if err := posix.MqNotify(mqd, &sev); err != nil {
+		t.Fatalf("MqNotify: %v", err)
+	}
+	if err := posix.MqNotify(mqd, &sev); !errors.Is(err, posix.EBUSY) {
+		t.Errorf("second MqNotify = %v, want EBUSY", err)
+	}
+	for range 2 {
+		if err := posix.MqSend(mqd, []byte("x"), 0); err != nil {
+			t.Fatalf("MqSend: %v", err)
+		}
+	}
+	select {
+	case <-sig:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no signal for a message on the empty queue")
+	}
+	select {
+	case <-sig:
+		t.Error("second signal without a new registration")
+	case <-time.After(20 * time.Millisecond):
+	}
+	if err := posix.MqNotify(mqd, &sev); err != nil {
+		t.Errorf("MqNotify after the notification: %v", err)
+	}
+	if err := posix.MqNotify(mqd, nil); err != nil {
+		t.Errorf("MqNotify(nil): %v", err)
+	}
+}
+
+// mqC receives one message from the queue argv[1] and sends it back, with its
+// priority plus one.
+const mqC = `#include <fcntl.h>
+#include <mqueue.h>
+#include <stdio.h>
+
+int main(int argc, char **argv) {
+	mqd_t q = mq_open(argv[1], O_RDWR);
+	if (q == (mqd_t)-1) { perror("mq_open"); return 1; }
+	char buf[64];
+	unsigned prio;
+	ssize_t n = mq_receive(q, buf, sizeof buf, &prio);
+	if (n < 0) { perror("mq_receive"); return 1; }
+	if (mq_send(q, buf, n, prio + 1) != 0) { perror("mq_send"); return 1; }
+	return mq_close(q);
+}
+`
+
+// TestMqWithC exchanges messages with a C program using <mqueue.h>.
+func TestMqWithC(t *testing.T) {
+	cc, err := exec.LookPath("cc")
+	if err != nil {
+		t.Skip("no C compiler")
+	}
+	dir := t.TempDir()
+	src := filepath.Join(dir, "mq.c")
+	if err := os.WriteFile(src, []byte(mqC), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	prog := filepath.Join(dir, "mq")
+	if out, err := exec.Command(cc, "-o", prog, src, "-lrt").CombinedOutput(); err != nil {
+		t.Skipf("cannot build the C program: %v\n%s", err, out)
+	}
+
+	name, mqd := mqOpen(t, 0)
+	cmd := exec.Command(prog, name)
+	cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if err := posix.MqSend(mqd, []byte("hello"), 3); err != nil {
+		t.Fatalf("MqSend: %v", err)
+	}
+	if err := cmd.Wait(); err != nil {
+		t.Fatalf("C program: %v", err)
+	}
+	buf := make([]byte, 64)
+	n, prio, err := posix.MqTimedReceive(mqd, buf, time.Now().Add(5*time.Second))
+	if err != nil {
+		t.Fatalf("MqTimedReceive: %v", err)
+	}
+	if string(buf[:n]) != "hello" || prio != 4 {
+		t.Errorf("reply = %q prio %d, want \"hello\" prio 4", buf[:n], prio)
+	}
+}
@@ -0,0 +1,75 @@
+package posix
+
+import "unsafe"
+
+//goland:noinspection GoSnakeCaseUsage
+const (
+	MREMAP_MAYMOVE   = 0x1 // the kernel may move the mapping to a new address
+	MREMAP_FIXED     = 0x2 // move the mapping to exactly the given address (MremapFixed)
+	MREMAP_DONTUNMAP = 0x4 // move the pages but leave the old range mapped (Linux 5.7+)
+)
+
+// Mremap grows, shrinks or moves a mapping made by Mmap — for example to pick
+// up the new size after Ftruncate grew the object. Without MREMAP_MAYMOVE the
+// mapping is resized in place or the call fails; with it the kernel may move
+// the mapping, in which case b must no longer be used.
+//
+// The mapping registry is updated under one lock: afterwards Munmap accepts the
+// returned slice and rejects b, unless MREMAP_DONTUNMAP kept b mapped, in which
+// case both are live. MREMAP_FIXED needs a destination address; use
+// MremapFixed for it.
+func Mremap(b []byte, newLen int, flags int) (data []byte, addr uintptr, err error) {
+	if newLen <= 0 || flags&MREMAP_FIXED != 0 {
+		return nil, 0, EINVAL
+	}
+	return mapper.Mremap(b, uintptr(newLen), flags, 0)
+}
+
+// MremapFixed moves a mapping made by Mmap to exactly address, as
+// mremap(MREMAP_MAYMOVE|MREMAP_FIXED) does. Like MAP_FIXED, anything already
+// mapped in the destination range is discarded; mappings there made through
+// this package are dropped from the registry. Moved into a Reservation, the
+// mapping becomes one of its commits: Munmap refuses it, and Decommit or
+// Release gives it back.
+func MremapFixed(b []byte, newLen int, flags int, address unsafe.Pointer) (data []byte, addr uintptr, err error) {
+	if newLen <= 0 || address == nil {
+		return nil, 0, EINVAL
+	}
+	return mapper.Mremap(b, uintptr(newLen), flags|MREMAP_MAYMOVE|MREMAP_FIXED, uintptr(address))
+}
+
+func (m *mmapper) Mremap(data []byte, newLen uintptr, flags int, newAddr uintptr) (b []byte, addr uintptr, err error) {
+	if len(data) == 0 || len(data) != cap(data) {
+		return nil, 0, EINVAL
+	}
+
+	// As in Munmap, only the exact slice Mmap returned may be remapped.
+	p := &data[0]
+	m.Lock()
+	defer m.Unlock()
+	mp, ok := m.active[p]
+	if !ok || &mp.data[0] != &data[0] || len(mp.data) != len(data) {
+		return nil, 0, EINVAL
+	}
+
+	addr, err = mremap(uintptr(unsafe.Pointer(p)), uintptr(len(data)), newLen, flags, newAddr)
+	if err != nil {
+		return nil, 0, err
+	}
+	b = unsafe.Slice((*byte)(unsafe.Pointer(addr)), newLen)
+
+	// Re-key the entry. The old key goes first, since an in-place resize keeps
+	// the same base byte; with MREMAP_DONTUNMAP the old range stays mapped and
+	// keeps its entry.
+	if flags&MREMAP_DONTUNMAP == 0 {
+		delete(m.active, p)
+	}
+	mp.data = b
+	if flags&MREMAP_FIXED != 0 {
+		m.forget(addr, newLen)
+		m.place(mp)
+		return b, addr, nil
+	}
+	m.active[&b[0]] = mp
+	return b, addr, nil
+}
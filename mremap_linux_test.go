@@ -0,0 +1,169 @@
+package posix_test
+
+import (
+	"errors"
+	"testing"
+	"unsafe"
+
+	"gopkg.in/ro-ag/posix.v1"
+)
+
+// TestMremapGrowObject: after Ftruncate grows an object, Mremap extends the
+// mapping over the new pages, and the registry follows the move — the new slice
+// unmaps, the old one is refused.
+func TestMremapGrowObject(t *testing.T) {
+	pg := posix.Getpagesize()
+	fd, err := posix.MemfdCreate("mremap", posix.MFD_ALLOW_SEALING)
+	if err != nil {
+		t.Fatalf("MemfdCreate: %v", err)
+	}
+	defer func() { _ = posix.Close(fd) }()
+	if err := posix.Ftruncate(fd, pg); err != nil {
+		t.Fatalf("Ftruncate: %v", err)
+	}
+	old, _, err := posix.Mmap(nil, pg, posix.PROT_RDWR, posix.MAP_SHARED, fd, 0)
+	if err != nil {
+		t.Fatalf("Mmap: %v", err)
+	}
+	old[0] = 0x11
+
+	if err := posix.Ftruncate(fd, 4*pg); err != nil {
+		t.Fatalf("Ftruncate (grow): %v", err)
+	}
+	grown, _, err := posix.Mremap(old, 4*pg, posix.MREMAP_MAYMOVE)
+	if err != nil {
+		t.Fatalf("Mremap: %v", err)
+	}
+	if len(grown) != 4*pg {
+		t.Fatalf("Mremap returned %d bytes, want %d", len(grown), 4*pg)
+	}
+	if grown[0] != 0x11 {
+		t.Errorf("grown[0] = %#x, want 0x11 — contents lost across Mremap", grown[0])
+	}
+	grown[4*pg-1] = 0x22 // the new pages are usable
+
+	if &old[0] != &grown[0] {
+		if err := posix.Munmap(old); err == nil {
+			t.Error("Munmap of the pre-move slice: want error, got nil")
+		}
+	}
+	if err := posix.Munmap(grown); err != nil {
+		t.Errorf("Munmap of the remapped slice: %v", err)
+	}
+}
+
+// TestMremapErrors: only a live, exact Mmap slice can be remapped, and
+// MREMAP_FIXED is routed through MremapFixed.
+func TestMremapErrors(t *testing.T) {
+	pg := posix.Getpagesize()
+	if _, _, err := posix.Mremap(make([]byte, pg), 2*pg, posix.MREMAP_MAYMOVE); !errors.Is(err, posix.EINVAL) {
+		t.Errorf("Mremap of a non-mapping = %v, want EINVAL", err)
+	}
+	buf, _, err := posix.Mmap(nil, 2*pg, posix.PROT_RDWR, posix.MAP_ANON|posix.MAP_PRIVATE, -1, 0)
+	if err != nil {
+		t.Fatalf("Mmap: %v", err)
+	}
+	defer func() { _ = posix.Munmap(buf) }()
+	if _, _, err := posix.Mremap(buf[:pg:pg], pg, posix.MREMAP_MAYMOVE); !errors.Is(err, posix.EINVAL) {
+		t.Errorf("Mremap of a resliced mapping = %v, want EINVAL", err)
+	}
+	if _, _, err := posix.Mremap(buf, pg, posix.MREMAP_FIXED); !errors.Is(err, posix.EINVAL) {
+		t.Errorf("Mremap with MREMAP_FIXED = %v, want EINVAL", err)
+	}
+}
+
+// TestMremapFixedAndDontUnmap moves a mapping to a chosen address with
+// MremapFixed, then copies it elsewhere with MREMAP_DONTUNMAP, which leaves
+// both the source and the destination registered.
+func TestMremapFixedAndDontUnmap(t *testing.T) {
+	pg := posix.Getpagesize()
+	const want = 0x31000000000
+	buf, _, err := posix.Mmap(nil, pg, posix.PROT_RDWR, posix.MAP_ANON|posix.MAP_PRIVATE, -1, 0)
+	if err != nil {
+		t.Fatalf("Mmap: %v", err)
+	}
+	buf[0] = 0x5a
+
+	moved, addr, err := posix.MremapFixed(buf, pg, 0, unsafe.Pointer(uintptr(want)))
+	if err != nil {
+		_ = posix.Munmap(buf)
+		t.Fatalf("MremapFixed: %v", err)
+	}
+	if addr != want || moved[0] != 0x5a {
+		t.Errorf("MremapFixed: addr %#x byte %#x, want %#x and 0x5a", addr, moved[0], uintptr(want))
+	}
+
+	dup, _, err := posix.Mremap(moved, pg, posix.MREMAP_MAYMOVE|posix.MREMAP_DONTUNMAP)
+	if err != nil {
+		_ = posix.Munmap(moved)
+		t.Skipf("MREMAP_DONTUNMAP unsupported here: %v", err)
+	}
+	if dup[0] != 0x5a {
+		t.Errorf("MREMAP_DONTUNMAP destination byte = %#x, want 0x5a", dup[0])
+	}
+	if err := posix.Munmap(dup); err != nil {
+		t.Errorf("Munmap (destination): %v", err)
+	}
+	if err := posix.Munmap(moved); err != nil {
+		t.Errorf("Munmap (source kept by MREMAP_DONTUNMAP): %v", err)
+	}
+}
+
+// TestMremapFixedIntoReservation moves mappings into a Reservation, where they
+// become its commits: Munmap refuses them, Decommit and Release give them back,
+// and once the range is released a stale slice cannot unmap what is mapped
+// there next.
+func TestMremapFixedIntoReservation(t *testing.T) {
+	pg := posix.Getpagesize()
+	r, err := posix.Reserve(nil, 4*pg)
+	if err != nil {
+		t.Fatalf("Reserve: %v", err)
+	}
+	base := r.Addr()
+	moveIn := func(off int) []byte {
+		t.Helper()
+		buf, _, err := posix.Mmap(nil, pg, posix.PROT_RDWR, posix.MAP_ANON|posix.MAP_PRIVATE, -1, 0)
+		if err != nil {
+			t.Fatalf("Mmap: %v", err)
+		}
+		moved, _, err := posix.MremapFixed(buf, pg, 0, unsafe.Pointer(base+uintptr(off)))
+		if err != nil {
+			_ = posix.Munmap(buf)
+			t.Fatalf("MremapFixed: %v", err)
+		}
+		moved[0] = 1
+		return moved
+	}
+
+	// Moved in at the very base, where it shares the reservation's first byte.
+	moved := moveIn(0)
+	if err := posix.Munmap(moved); !errors.Is(err, posix.EINVAL) {
+		t.Errorf("Munmap of a mapping moved into the reservation = %v, want EINVAL", err)
+	}
+	if _, err := r.Commit(0, pg, -1, 0, posix.PROT_RDWR); !errors.Is(err, posix.ErrAddressInUse) {
+		t.Errorf("Commit over the moved mapping = %v, want ErrAddressInUse", err)
+	}
+	if err := r.Decommit(0, pg); err != nil {
+		t.Fatalf("Decommit of the moved mapping: %v", err)
+	}
+	if _, err := r.Commit(0, pg, -1, 0, posix.PROT_RDWR); err != nil {
+		t.Errorf("Commit after Decommit: %v", err)
+	}
+
+	moved = moveIn(2 * pg)
+	if err := r.Release(); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	next, err := posix.MmapAt(unsafe.Pointer(base), 4*pg, posix.PROT_RDWR, posix.MAP_ANON|posix.MAP_PRIVATE, -1, 0)
+	if err != nil {
+		t.Skipf("MmapAt over the released range: %v", err)
+	}
+	defer func() { _ = posix.Munmap(next) }()
+	if err := posix.Munmap(moved); !errors.Is(err, posix.EINVAL) {
+		t.Errorf("Munmap of the moved mapping after Release = %v, want EINVAL", err)
+	}
+	next[2*pg] = 2
+	if _, err := posix.Mincore(next); err != nil {
+		t.Errorf("Mincore of the mapping made after Release: %v", err)
+	}
+}
@@ -0,0 +1,209 @@
+package posix
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	// ErrOwnerDead is returned by SharedMutex.Lock when the previous owner died
+	// holding the lock. The caller now holds it and must repair the state it
+	// protects, then call Consistent.
+	ErrOwnerDead error = syscall.EOWNERDEAD
+	// ErrNotRecoverable is returned by SharedMutex.Lock once a mutex whose owner
+	// died has been unlocked without Consistent: the state it protects is lost.
+	ErrNotRecoverable error = syscall.ENOTRECOVERABLE
+)
+
+// Futex word bits of a robust futex, shared with the kernel.
+const (
+	futexWaiters   = 0x80000000
+	futexOwnerDied = 0x40000000
+	futexTIDMask   = 0x3fffffff
+)
+
+// SharedMutex states.
+const (
+	mutexConsistent     = 0
+	mutexInconsistent   = 1 // locked with ErrOwnerDead, Consistent not yet called
+	mutexNotRecoverable = 2
+)
+
+// SharedMutex is a mutual exclusion lock that lives in shared memory and works
+// across processes: lay it over a mapping with View and have every process lock
+// the same one. Its zero value is an unlocked mutex, so a freshly truncated
+// object needs no initialization.
+//
+// It is a robust futex. The lock word records which process holds it and is
+// registered with the kernel (set_robust_list) while held, so if the holder
+// dies the kernel releases the lock and marks it; the next Lock then succeeds
+// with ErrOwnerDead instead of blocking forever. The new holder repairs the
+// shared state and calls Consistent; unlocking without doing so makes the
+// mutex permanently unusable (ErrNotRecoverable), as for a POSIX robust mutex.
+//
+// Ownership is per process, not per goroutine: like sync.Mutex, a SharedMutex
+// may be unlocked by a goroutine other than the one that locked it, even
+// through another mapping of it. The mapping it was locked through must stay
+// mapped until it is unlocked.
+type SharedMutex struct {
+	next  uint64        // robust list link, in the holder's address space
+	word  atomic.Uint32 // holder's robust-list thread ID | futexWaiters | futexOwnerDied
+	state atomic.Uint32
+	owner atomic.Int32 // holder's PID, for Owner
+	_     uint32
+	entry uint64 // address the holder listed m at; another mapping of m may unlock it
+}
+
+// robustFutexOffset is the distance from a list entry (SharedMutex.next) to its
+// futex word, as the kernel needs it.
+const robustFutexOffset = int64(unsafe.Offsetof(SharedMutex{}.word))
+
+// robust is this process's robust list. The kernel walks a robust list when
+// the thread that registered it exits, and only releases futexes that carry
+// that thread's ID, so the list is registered by one thread that never exits
+// while the process lives, and its ID is the one written into lock words.
+var robust robustList
+
+type robustList struct {
+	once sync.Once
+	mu   sync.Mutex // guards head
+	head struct {   // struct robust_list_head
+		list    uint64 // first entry; &head when empty
+		offset  int64  // robustFutexOffset
+		pending uint64 // entry being locked or unlocked
+	}
+	tid uint32
+	err error
+}
+
+func robustInit() error {
+	robust.once.Do(func() {
+		done := make(chan struct{})
+		go func() {
+			runtime.LockOSThread()
+			robust.head.list = uint64(uintptr(unsafe.Pointer(&robust.head)))
+			robust.head.offset = robustFutexOffset
+			robust.tid = uint32(syscall.Gettid())
+			robust.err = setRobustList(unsafe.Pointer(&robust.head), unsafe.Sizeof(robust.head))
+			close(done)
+			select {} // keep the thread, and with it the list, alive
+		}()
+		<-done
+	})
+	return robust.err
+}
+
+// Lock locks m, blocking until it is available. If the previous holder died
+// with the lock held, Lock returns ErrOwnerDead with m locked; see Consistent.
+// It fails with ErrNotRecoverable, without locking, if m was abandoned.
+func (m *SharedMutex) Lock() error {
+	return m.lock(true)
+}
+
+// TryLock is Lock that fails with EBUSY rather than block.
+func (m *SharedMutex) TryLock() error {
+	return m.lock(false)
+}
+
+func (m *SharedMutex) lock(block bool) error {
+	if err := robustInit(); err != nil {
+		return err
+	}
+	if m.state.Load() == mutexNotRecoverable {
+		return ErrNotRecoverable
+	}
+	word := (*uint32)(unsafe.Pointer(&m.word))
+	self := uint64(uintptr(unsafe.Pointer(m)))
+	var waiters uint32 // once we have slept, others may be asleep too
+	for {
+		w := m.word.Load()
+		if w&futexTIDMask == 0 {
+			// Take the lock and list it as one step as far as the kernel is
+			// concerned: should we die in between, pending covers it.
+			robust.mu.Lock()
+			robust.head.pending = self
+			ok := m.word.CompareAndSwap(w, robust.tid|waiters|w&futexWaiters)
+			if ok {
+				m.next = robust.head.list
+				m.entry = self
+				robust.head.list = self
+			}
+			robust.head.pending = 0
+			robust.mu.Unlock()
+			if !ok {
+				continue
+			}
+			m.owner.Store(int32(syscall.Getpid()))
+			if w&futexOwnerDied != 0 {
+				m.state.Store(mutexInconsistent)
+				return ErrOwnerDead
+			}
+			if m.state.Load() == mutexNotRecoverable {
+				// Abandoned while we waited; pass the news on.
+				_ = m.Unlock()
+				return ErrNotRecoverable
+			}
+			return nil
+		}
+		if !block {
+			return EBUSY
+		}
+		if w&futexWaiters == 0 && !m.word.CompareAndSwap(w, w|futexWaiters) {
+			continue
+		}
+		_ = FutexWait(word, w|futexWaiters, -1)
+		waiters = futexWaiters
+	}
+}
+
+// Unlock unlocks m. It fails with EPERM if m is not locked by this process.
+// Unlocking after ErrOwnerDead without Consistent marks m not recoverable.
+func (m *SharedMutex) Unlock() error {
+	if err := robustInit(); err != nil {
+		return err
+	}
+	if m.word.Load()&futexTIDMask != robust.tid {
+		return EPERM
+	}
+	m.state.CompareAndSwap(mutexInconsistent, mutexNotRecoverable)
+	robust.mu.Lock()
+	robust.head.pending = m.entry
+	robust.unlink(m.entry)
+	m.owner.Store(0)
+	w := m.word.Swap(0)
+	robust.head.pending = 0
+	robust.mu.Unlock()
+	if w&futexWaiters != 0 {
+		_, _ = FutexWake((*uint32)(unsafe.Pointer(&m.word)), 1)
+	}
+	return nil
+}
+
+// Consistent marks the state protected by m as repaired after Lock returned
+// ErrOwnerDead; the mutex is then an ordinary one again. It fails with EINVAL
+// if m is not in that state.
+func (m *SharedMutex) Consistent() error {
+	if !m.state.CompareAndSwap(mutexInconsistent, mutexConsistent) {
+		return EINVAL
+	}
+	return nil
+}
+
+// Owner returns the PID of the process holding m, or 0 if it is unlocked.
+func (m *SharedMutex) Owner() int {
+	return int(m.owner.Load())
+}
+
+// unlink removes entry from the robust list. The caller holds r.mu.
+func (r *robustList) unlink(entry uint64) {
+	end := uint64(uintptr(unsafe.Pointer(&r.head)))
+	for link := &r.head.list; *link != end; link = (*uint64)(unsafe.Pointer(uintptr(*link))) {
+		if *link == entry {
+			*link = *(*uint64)(unsafe.Pointer(uintptr(entry)))
+			return
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package posix_test
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+	"unsafe"
+
+	"gopkg.in/ro-ag/posix.v1"
+)
+
+// sharedMutexPage maps one page of fd and returns the mutex at its start.
+func sharedMutexPage(t *testing.T, fd int) *posix.SharedMutex {
+	t.Helper()
+	b, _, err := posix.Mmap(nil, posix.Getpagesize(), posix.PROT_RDWR, posix.MAP_SHARED, fd, 0)
+	if err != nil {
+		t.Fatalf("Mmap: %v", err)
+	}
+	t.Cleanup(func() { _ = posix.Munmap(b) })
+	m, err := posix.View[posix.SharedMutex](b, 0)
+	if err != nil {
+		t.Fatalf("View: %v", err)
+	}
+	return m
+}
+
+// TestSharedMutexExclusion: goroutines incrementing a counter under the mutex,
+// through two mappings of it, lose no updates.
+func TestSharedMutexExclusion(t *testing.T) {
+	fd, err := posix.MemfdCreate("mutex", 0)
+	if err != nil {
+		t.Fatalf("MemfdCreate: %v", err)
+	}
+	defer func() { _ = posix.Close(fd) }()
+	if err := posix.Ftruncate(fd, posix.Getpagesize()); err != nil {
+		t.Fatal(err)
+	}
+	ms := [2]*posix.SharedMutex{sharedMutexPage(t, fd), sharedMutexPage(t, fd)}
+	// The counter lives in the mapping too, where the race detector, which
+	// cannot see futex-based synchronization, does not look.
+	counter := (*int)(unsafe.Add(unsafe.Pointer(ms[0]), unsafe.Sizeof(posix.SharedMutex{})))
+
+	if err := ms[0].Lock(); err != nil {
+		t.Fatalf("Lock: %v", err)
+	}
+	if ms[1].Owner() != os.Getpid() {
+		t.Errorf("Owner = %d, want %d", ms[1].Owner(), os.Getpid())
+	}
+	if err := ms[1].TryLock(); !errors.Is(err, posix.EBUSY) {
+		t.Errorf("TryLock on a held mutex = %v, want EBUSY", err)
+	}
+	if err := ms[1].Unlock(); err != nil {
+		t.Fatalf("Unlock: %v", err)
+	}
+	if err := ms[1].Unlock(); !errors.Is(err, posix.EPERM) {
+		t.Errorf("Unlock of an unlocked mutex = %v, want EPERM", err)
+	}
+
+	var wg sync.WaitGroup
+	for g := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			m := ms[g%2]
+			for range 2000 {
+				if err := m.Lock(); err != nil {
+					t.Errorf("Lock: %v", err)
+					return
+				}
+				*counter++
+				if err := m.Unlock(); err != nil {
+					t.Errorf("Unlock: %v", err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if *counter != 8*2000 {
+		t.Errorf("counter = %d, want %d", *counter, 8*2000)
+	}
+}
+
+const mutexChildEnv = "POSIX_MUTEX_CHILD"
+
+// TestSharedMutexOwnerDead kills a child process while it holds the mutex. A
+// locker already waiting is woken with ErrOwnerDead, and after Consistent the
+// mutex works again. The second time the state is not repaired, so the mutex
+// becomes not recoverable.
+func TestSharedMutexOwnerDead(t *testing.T) {
+	if os.Getenv(mutexChildEnv) != "" {
+		mutexChild(t)
+		return
+	}
+	fd, err := posix.MemfdCreate("mutex", 0)
+	if err != nil {
+		t.Fatalf("MemfdCreate: %v", err)
+	}
+	defer func() { _ = posix.Close(fd) }()
+	if err := posix.Ftruncate(fd, posix.Getpagesize()); err != nil {
+		t.Fatal(err)
+	}
+	m := sharedMutexPage(t, fd)
+
+	holder := startMutexHolder(t, fd)
+	if m.Owner() != holder.Process.Pid {
+		t.Errorf("Owner = %d, want the child's PID %d", m.Owner(), holder.Process.Pid)
+	}
+	done := make(chan error, 1)
+	go func() { done <- m.Lock() }()
+	time.Sleep(20 * time.Millisecond) // let the locker block
+	_ = holder.Process.Kill()
+	_ = holder.Wait()
+	select {
+	case err := <-done:
+		if !errors.Is(err, posix.ErrOwnerDead) {
+			t.Fatalf("Lock after the holder died = %v, want ErrOwnerDead", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("waiting Lock not woken when the holder died")
+	}
+	if err := m.Consistent(); err != nil {
+		t.Fatalf("Consistent: %v", err)
+	}
+	if err := m.Consistent(); !errors.Is(err, posix.EINVAL) {
+		t.Errorf("second Consistent = %v, want EINVAL", err)
+	}
+	if err := m.Unlock(); err != nil {
+		t.Fatalf("Unlock: %v", err)
+	}
+	if err := m.Lock(); err != nil {
+		t.Fatalf("Lock after recovery: %v", err)
+	}
+	if err := m.Unlock(); err != nil {
+		t.Fatalf("Unlock: %v", err)
+	}
+
+	holder = startMutexHolder(t, fd)
+	_ = holder.Process.Kill()
+	_ = holder.Wait()
+	if err := m.Lock(); !errors.Is(err, posix.ErrOwnerDead) {
+		t.Fatalf("Lock after the second holder died = %v, want ErrOwnerDead", err)
+	}
+	if err := m.Unlock(); err != nil {
+		t.Fatalf("Unlock without Consistent: %v", err)
+	}
+	if err := m.Lock(); !errors.Is(err, posix.ErrNotRecoverable) {
+		t.Errorf("Lock of an abandoned mutex = %v, want ErrNotRecoverable", err)
+	}
+}
+
+// startMutexHolder re-executes the test binary as a child that locks the mutex
+// in fd and holds it, and returns once the child reports it is locked.
+func startMutexHolder(t *testing.T, fd int) *exec.Cmd {
+	t.Helper()
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dup, err := syscall.Dup(fd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := os.NewFile(uintptr(dup), "mutex")
+	defer func() { _ = f.Close() }()
+	cmd := exec.Command(exe, "-test.run=^TestSharedMutexOwnerDead$")
+	cmd.Env = append(os.Environ(), mutexChildEnv+"=1")
+	cmd.ExtraFiles = []*os.File{f} // fd 3 in the child
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("start child: %v", err)
+	}
+	sc := bufio.NewScanner(out)
+	for sc.Scan() {
+		if sc.Text() == "locked" {
+			return cmd
+		}
+	}
+	_ = cmd.Wait()
+	t.Fatal("child exited without locking the mutex")
+	return nil
+}
+
+// mutexChild is the body of the re-executed holder: lock, report, and wait to
+// be killed.
+func mutexChild(t *testing.T) {
+	m := sharedMutexPage(t, 3)
+	if err := m.Lock(); err != nil {
+		t.Fatalf("child Lock: %v", err)
+	}
+	fmt.Println("locked")
+	time.Sleep(time.Minute)
+	t.Fatal("child was not killed")
+}
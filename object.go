@@ -0,0 +1,164 @@
+//go:build darwin || linux
+
+package posix
+
+import (
+	"errors"
+	"sync"
+	"unsafe"
+)
+
+// ErrClosed is returned by the methods of a handle (Object, Mapping, …) once it
+// has been closed.
+var ErrClosed = errors.New("posix: use of closed handle")
+
+// Object is a handle on a named shared-memory object. It owns the descriptor
+// returned by ShmOpen together with the object's name and size, so the usual
+// ShmOpen / Ftruncate / Mmap / Munmap / Close sequence collapses into a value
+// that cannot be closed twice.
+//
+// Mappings made with Object.Mmap or Object.Map are tracked by the same registry
+// as Mmap; they may be released early with Munmap or Mapping.Close, and any
+// still live are unmapped by Close.
+// An Object is safe for concurrent use.
+type Object struct {
+	mu     sync.Mutex
+	fd     int
+	name   string
+	size   int
+	maps   []*Mapping // handed out by Map, closed along with the Object
+	closed bool
+}
+
+// CreateObject creates the shared-memory object name (it must not exist yet),
+// sizes it to size bytes, and returns a handle on it. perm sets its permission
+// bits exactly as the mode argument of ShmOpen does. If sizing it or reading
+// its size back fails, the half-made object is closed and unlinked.
+func CreateObject(name string, size int, perm uint32) (*Object, error) {
+	fd, err := ShmOpen(name, O_RDWR|O_CREAT|O_EXCL, perm)
+	if err != nil {
+		return nil, err
+	}
+	if err = Ftruncate(fd, size); err != nil {
+		_ = Close(fd)
+		_ = ShmUnlink(name)
+		return nil, err
+	}
+	o, err := newObject(fd, name)
+	if err != nil {
+		_ = ShmUnlink(name)
+		return nil, err
+	}
+	return o, nil
+}
+
+// OpenObject opens the existing shared-memory object name for reading and
+// writing and returns a handle on it; its size is read from the object.
+func OpenObject(name string) (*Object, error) {
+	fd, err := ShmOpen(name, O_RDWR, 0)
+	if err != nil {
+		return nil, err
+	}
+	return newObject(fd, name)
+}
+
+// newObject wraps an open descriptor, reading the size back with Fstat (macOS
+// rounds it up to a page). The descriptor is closed if that fails.
+func newObject(fd int, name string) (*Object, error) {
+	var st Stat_t
+	if err := Fstat(fd, &st); err != nil {
+		_ = Close(fd)
+		return nil, err
+	}
+	return &Object{fd: fd, name: name, size: int(st.Size)}, nil
+}
+
+// Fd returns the underlying descriptor, or -1 once the object is closed.
+func (o *Object) Fd() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.closed {
+		return -1
+	}
+	return o.fd
+}
+
+// Name returns the name the object was created or opened under.
+func (o *Object) Name() string {
+	return o.name
+}
+
+// Size returns the object's size in bytes as last set or observed by this
+// handle.
+func (o *Object) Size() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.size
+}
+
+// Truncate resizes the object to size bytes; see Ftruncate for the seal and
+// macOS restrictions. Existing mappings keep their length.
+func (o *Object) Truncate(size int) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.closed {
+		return ErrClosed
+	}
+	if err := Ftruncate(o.fd, size); err != nil {
+		return err
+	}
+	o.size = size
+	return nil
+}
+
+// Mmap maps length bytes of the object starting at offset; the arguments mean
+// what they do for Mmap. A length of zero maps the whole object.
+func (o *Object) Mmap(address unsafe.Pointer, length int, prot int, flags int, offset int64) (data []byte, addr uintptr, err error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.mmap(address, length, prot, flags, offset)
+}
+
+// mmap is Mmap for a caller that holds o.mu.
+func (o *Object) mmap(address unsafe.Pointer, length int, prot int, flags int, offset int64) (data []byte, addr uintptr, err error) {
+	if o.closed {
+		return nil, 0, ErrClosed
+	}
+	if length == 0 {
+		length = o.size - int(offset)
+	}
+	data, addr, err = Mmap(address, length, prot, flags, o.fd, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	mapper.own(data, o)
+	return data, addr, nil
+}
+
+// Unlink removes the object's name, as ShmUnlink does. The object itself lives
+// on until every descriptor and mapping of it is gone.
+func (o *Object) Unlink() error {
+	return ShmUnlink(o.name)
+}
+
+// Close unmaps any mappings made through the handle that are still live, closes
+// the Mappings Map returned, and closes its descriptor. Mappings made with
+// plain Mmap are left alone, even those of a descriptor that had the same
+// number. It does not unlink the name. A second Close returns ErrClosed.
+func (o *Object) Close() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.closed {
+		return ErrClosed
+	}
+	o.closed = true
+	// Each Mapping is marked closed under its own lock before the unmap, so
+	// an access either finishes first or sees ErrClosed.
+	for _, m := range o.maps {
+		m.mu.Lock()
+		m.markClosed()
+		m.mu.Unlock()
+	}
+	o.maps = nil
+	return errors.Join(mapper.munmapOwner(o), Close(o.fd))
+}
@@ -0,0 +1,142 @@
+//go:build darwin || linux
+
+package posix_test
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"testing"
+
+	"gopkg.in/ro-ag/posix.v1"
+)
+
+var _ io.Closer = (*posix.Object)(nil)
+
+// TestObjectLifecycle drives an Object through create, map, reopen by name and
+// close: data written through one handle is visible through the other, Close
+// unmaps what was left mapped, and a second Close is refused.
+func TestObjectLifecycle(t *testing.T) {
+	pg := posix.Getpagesize()
+	name := fmt.Sprintf("/posix-obj-%d", os.Getpid())
+	obj, err := posix.CreateObject(name, pg, 0o600)
+	if err != nil {
+		t.Fatalf("CreateObject: %v", err)
+	}
+	defer func() { _ = obj.Unlink() }()
+
+	if obj.Size() != pg {
+		t.Errorf("Size() = %d, want %d", obj.Size(), pg)
+	}
+	if obj.Name() != name {
+		t.Errorf("Name() = %q, want %q", obj.Name(), name)
+	}
+	buf, _, err := obj.Mmap(nil, 0, posix.PROT_RDWR, posix.MAP_SHARED, 0)
+	if err != nil {
+		t.Fatalf("Mmap: %v", err)
+	}
+	if len(buf) != pg {
+		t.Fatalf("Mmap(length=0) mapped %d bytes, want the whole object (%d)", len(buf), pg)
+	}
+	copy(buf, "object")
+
+	other, err := posix.OpenObject(name)
+	if err != nil {
+		t.Fatalf("OpenObject: %v", err)
+	}
+	if other.Size() != pg {
+		t.Errorf("OpenObject Size() = %d, want %d", other.Size(), pg)
+	}
+	view, _, err := other.Mmap(nil, 0, posix.PROT_READ, posix.MAP_SHARED, 0)
+	if err != nil {
+		t.Fatalf("Mmap (second handle): %v", err)
+	}
+	if got := string(view[:6]); got != "object" {
+		t.Errorf("second handle sees %q, want %q", got, "object")
+	}
+	if err := posix.Munmap(view); err != nil {
+		t.Errorf("Munmap of an Object mapping: %v", err)
+	}
+	if err := other.Close(); err != nil {
+		t.Errorf("Close (second handle): %v", err)
+	}
+
+	// buf is still mapped: Close must release it.
+	if err := obj.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if err := posix.Munmap(buf); err == nil {
+		t.Error("Munmap after Close: want error (Close should have unmapped it), got nil")
+	}
+	if err := obj.Close(); !errors.Is(err, posix.ErrClosed) {
+		t.Errorf("second Close = %v, want ErrClosed", err)
+	}
+	if _, _, err := obj.Mmap(nil, 0, posix.PROT_READ, posix.MAP_SHARED, 0); !errors.Is(err, posix.ErrClosed) {
+		t.Errorf("Mmap after Close = %v, want ErrClosed", err)
+	}
+	if fd := obj.Fd(); fd != -1 {
+		t.Errorf("Fd() after Close = %d, want -1", fd)
+	}
+}
+
+// TestCreateObjectExisting: creating over an existing name fails and must not
+// unlink the object that was already there.
+func TestCreateObjectExisting(t *testing.T) {
+	pg := posix.Getpagesize()
+	name := fmt.Sprintf("/posix-obj-excl-%d", os.Getpid())
+	obj, err := posix.CreateObject(name, pg, 0o600)
+	if err != nil {
+		t.Fatalf("CreateObject: %v", err)
+	}
+	defer func() { _ = obj.Unlink(); _ = obj.Close() }()
+
+	if dup, err := posix.CreateObject(name, pg, 0o600); err == nil {
+		_ = dup.Close()
+		t.Fatal("CreateObject on an existing name: want error, got nil")
+	}
+	again, err := posix.OpenObject(name)
+	if err != nil {
+		t.Fatalf("OpenObject after a failed CreateObject: %v — the original was unlinked", err)
+	}
+	_ = again.Close()
+}
+
+// TestObjectCloseReusedFd: a mapping outlives the descriptor it was made from,
+// and an Object that gets the same descriptor number must not unmap it.
+func TestObjectCloseReusedFd(t *testing.T) {
+	pg := posix.Getpagesize()
+	other := fmt.Sprintf("/posix-obj-other-%d", os.Getpid())
+	fd, err := posix.ShmOpen(other, posix.O_RDWR|posix.O_CREAT|posix.O_EXCL, 0o600)
+	if err != nil {
+		t.Fatalf("ShmOpen: %v", err)
+	}
+	defer func() { _ = posix.ShmUnlink(other) }()
+	if err := posix.Ftruncate(fd, pg); err != nil {
+		t.Fatalf("Ftruncate: %v", err)
+	}
+	buf, _, err := posix.Mmap(nil, pg, posix.PROT_RDWR, posix.MAP_SHARED, fd, 0)
+	if err != nil {
+		t.Fatalf("Mmap: %v", err)
+	}
+	if err := posix.Close(fd); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	name := fmt.Sprintf("/posix-obj-reuse-%d", os.Getpid())
+	obj, err := posix.CreateObject(name, pg, 0o600)
+	if err != nil {
+		t.Fatalf("CreateObject: %v", err)
+	}
+	defer func() { _ = obj.Unlink() }()
+	if obj.Fd() != fd {
+		t.Logf("CreateObject got fd %d, not the reused %d", obj.Fd(), fd)
+	}
+	if err := obj.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	copy(buf, "still mapped")
+	if err := posix.Munmap(buf); err != nil {
+		t.Errorf("Munmap after an Object with the same fd closed: %v", err)
+	}
+}
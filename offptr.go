@@ -0,0 +1,177 @@
+//go:build darwin || linux
+
+package posix
+
+import (
+	"fmt"
+	"iter"
+	"unsafe"
+)
+
+// Off is a pointer to a T stored as its offset from the start of a region. It
+// stays valid wherever the region is mapped, so it can be used when processes
+// cannot agree on a base address (see AttachBase). The zero Off is nil; offset
+// 0 itself is the start of the region, which is where headers live.
+//
+// Get and Set panic, as an out-of-range slice index does, if the target does
+// not lie inside base or is misaligned or not shareable (see View).
+type Off[T any] struct {
+	off uint64
+}
+
+// IsNil reports whether o is nil.
+func (o Off[T]) IsNil() bool { return o.off == 0 }
+
+// Offset returns the offset o holds.
+func (o Off[T]) Offset() uint64 { return o.off }
+
+// Get returns the T that o refers to inside base, or nil.
+func (o Off[T]) Get(base []byte) *T {
+	if o.off == 0 {
+		return nil
+	}
+	return mustView[T](base, int(o.off))
+}
+
+// Set makes o refer to p, which must lie inside base, or be nil.
+func (o *Off[T]) Set(base []byte, p *T) {
+	if p == nil {
+		o.off = 0
+		return
+	}
+	o.off = uint64(offsetIn(base, unsafe.Pointer(p)))
+}
+
+// Rel is a self-relative pointer: it stores the distance from its own address to
+// the T it refers to. Unlike Off it needs no agreed region start — only that the
+// Rel and its target move together — which makes it the link of choice for
+// structures built inside a mapping (see ListNode and TreeNode). A Rel is only
+// meaningful in place: use it through a pointer into the region, never a copy.
+// The zero Rel is nil.
+//
+// Get and Set take the region only to check bounds, and panic like Off's if the
+// Rel or its target is not inside it.
+type Rel[T any] struct {
+	off int64
+}
+
+// IsNil reports whether r is nil.
+func (r *Rel[T]) IsNil() bool { return r.off == 0 }
+
+// Get returns the T that r refers to, or nil.
+func (r *Rel[T]) Get(base []byte) *T {
+	if r.off == 0 {
+		return nil
+	}
+	self := offsetIn(base, unsafe.Pointer(r))
+	return mustView[T](base, self+int(r.off))
+}
+
+// Set makes r refer to p, or nil. Both r and p must lie inside base.
+func (r *Rel[T]) Set(base []byte, p *T) {
+	if p == nil {
+		r.off = 0
+		return
+	}
+	self := offsetIn(base, unsafe.Pointer(r))
+	r.off = int64(offsetIn(base, unsafe.Pointer(p)) - self)
+}
+
+// offsetIn returns the offset of p inside base, panicking if it is outside.
+func offsetIn(base []byte, p unsafe.Pointer) int {
+	if len(base) == 0 {
+		panic("posix: pointer outside region")
+	}
+	start := uintptr(unsafe.Pointer(&base[0]))
+	if uintptr(p) < start || uintptr(p)-start >= uintptr(len(base)) {
+		panic(fmt.Sprintf("posix: pointer %p outside region [%#x, %#x)", p, start, start+uintptr(len(base))))
+	}
+	return int(uintptr(p) - start)
+}
+
+func mustView[T any](base []byte, off int) *T {
+	p, err := View[T](base, off)
+	if err != nil {
+		panic(fmt.Sprintf("posix: offset %d: %v", off, err))
+	}
+	return p
+}
+
+// ListNode is a node of a singly linked list kept inside a region. The nodes
+// themselves are allocated by the caller (with SliceOf, or an Arena).
+type ListNode[T any] struct {
+	Next  Rel[ListNode[T]]
+	Value T
+}
+
+// ListPush links n in at the front of the list whose head is *head. head and n
+// must both lie inside base.
+func ListPush[T any](base []byte, head *Rel[ListNode[T]], n *ListNode[T]) {
+	n.Next.Set(base, head.Get(base))
+	head.Set(base, n)
+}
+
+// ListAll iterates over the list whose head is *head, front to back.
+func ListAll[T any](base []byte, head *Rel[ListNode[T]]) iter.Seq[*ListNode[T]] {
+	return func(yield func(*ListNode[T]) bool) {
+		for n := head.Get(base); n != nil; n = n.Next.Get(base) {
+			if !yield(n) {
+				return
+			}
+		}
+	}
+}
+
+// TreeNode is a node of a binary search tree kept inside a region. The tree is
+// not balanced; it suits lookups over data that arrives in no particular order.
+type TreeNode[T any] struct {
+	Left, Right Rel[TreeNode[T]]
+	Value       T
+}
+
+// TreeInsert links n into the tree rooted at *root, ordered by cmp. Equal
+// values go to the right, after the ones already there.
+func TreeInsert[T any](base []byte, root *Rel[TreeNode[T]], n *TreeNode[T], cmp func(a, b T) int) {
+	n.Left.Set(base, nil)
+	n.Right.Set(base, nil)
+	link := root
+	for cur := link.Get(base); cur != nil; cur = link.Get(base) {
+		if cmp(n.Value, cur.Value) < 0 {
+			link = &cur.Left
+		} else {
+			link = &cur.Right
+		}
+	}
+	link.Set(base, n)
+}
+
+// TreeFind returns the first node of the tree rooted at *root whose value
+// compares equal to v, or nil.
+func TreeFind[T any](base []byte, root *Rel[TreeNode[T]], v T, cmp func(a, b T) int) *TreeNode[T] {
+	cur := root.Get(base)
+	for cur != nil {
+		switch c := cmp(v, cur.Value); {
+		case c == 0:
+			return cur
+		case c < 0:
+			cur = cur.Left.Get(base)
+		default:
+			cur = cur.Right.Get(base)
+		}
+	}
+	return nil
+}
+
+// TreeAll iterates over the tree rooted at *root in order.
+func TreeAll[T any](base []byte, root *Rel[TreeNode[T]]) iter.Seq[*TreeNode[T]] {
+	return func(yield func(*TreeNode[T]) bool) {
+		treeWalk(base, root.Get(base), yield)
+	}
+}
+
+func treeWalk[T any](base []byte, n *TreeNode[T], yield func(*TreeNode[T]) bool) bool {
+	if n == nil {
+		return true
+	}
+	return treeWalk(base, n.Left.Get(base), yield) && yield(n) && treeWalk(base, n.Right.Get(base), yield)
+}
@@ -0,0 +1,143 @@
+//go:build darwin || linux
+
+package posix_test
+
+import (
+	"cmp"
+	"testing"
+
+	"gopkg.in/ro-ag/posix.v1"
+)
+
+// offHeader sits at the start of the test region: the list and tree roots.
+type offHeader struct {
+	List posix.Rel[posix.ListNode[uint64]]
+	Tree posix.Rel[posix.TreeNode[uint64]]
+	Last posix.Off[posix.ListNode[uint64]]
+}
+
+// TestOffsetPointersRelocate builds a list and a tree inside one mapping, copies
+// the bytes to a second mapping at a different address, and walks both there:
+// relative and offset pointers must not care where the region lives.
+func TestOffsetPointersRelocate(t *testing.T) {
+	pg := posix.Getpagesize()
+	src := mapAnon(t, pg)
+	dst := mapAnon(t, pg)
+
+	h, err := posix.View[offHeader](src, 0)
+	if err != nil {
+		t.Fatalf("View: %v", err)
+	}
+	list, err := posix.SliceOf[posix.ListNode[uint64]](src, 64, 5)
+	if err != nil {
+		t.Fatalf("SliceOf(list): %v", err)
+	}
+	for i := range list {
+		list[i].Value = uint64(i)
+		posix.ListPush(src, &h.List, &list[i])
+	}
+	h.Last.Set(src, &list[len(list)-1])
+
+	tree, err := posix.SliceOf[posix.TreeNode[uint64]](src, 512, 7)
+	if err != nil {
+		t.Fatalf("SliceOf(tree): %v", err)
+	}
+	for i, v := range []uint64{50, 20, 80, 10, 30, 70, 90} {
+		tree[i].Value = v
+		posix.TreeInsert(src, &h.Tree, &tree[i], cmp.Compare[uint64])
+	}
+
+	copy(dst, src)
+	clear(src) // nothing may still point back into src
+	h2, err := posix.View[offHeader](dst, 0)
+	if err != nil {
+		t.Fatalf("View (copy): %v", err)
+	}
+
+	var got []uint64
+	for n := range posix.ListAll(dst, &h2.List) {
+		got = append(got, n.Value)
+	}
+	if want := []uint64{4, 3, 2, 1, 0}; !equal(got, want) {
+		t.Errorf("list in the copy = %v, want %v", got, want)
+	}
+	if last := h2.Last.Get(dst); last == nil || last.Value != 4 {
+		t.Errorf("Off in the copy = %v, want the node holding 4", last)
+	}
+
+	got = got[:0]
+	for n := range posix.TreeAll(dst, &h2.Tree) {
+		got = append(got, n.Value)
+	}
+	if want := []uint64{10, 20, 30, 50, 70, 80, 90}; !equal(got, want) {
+		t.Errorf("tree in order in the copy = %v, want %v", got, want)
+	}
+	if n := posix.TreeFind(dst, &h2.Tree, 70, cmp.Compare[uint64]); n == nil || n.Value != 70 {
+		t.Errorf("TreeFind(70) = %v, want the node holding 70", n)
+	}
+	if n := posix.TreeFind(dst, &h2.Tree, 60, cmp.Compare[uint64]); n != nil {
+		t.Errorf("TreeFind(60) = %v, want nil", n)
+	}
+}
+
+// TestOffsetPointerBounds: nil pointers round-trip, and a pointer outside the
+// region panics instead of being stored.
+func TestOffsetPointerBounds(t *testing.T) {
+	region := mapAnon(t, posix.Getpagesize())
+	var off posix.Off[uint64]
+	if !off.IsNil() || off.Get(region) != nil {
+		t.Error("zero Off is not nil")
+	}
+	outside := new(uint64)
+	if !panics(func() { off.Set(region, outside) }) {
+		t.Error("Off.Set with a pointer outside the region did not panic")
+	}
+	r, err := posix.View[posix.Rel[uint64]](region, 0)
+	if err != nil {
+		t.Fatalf("View: %v", err)
+	}
+	if !panics(func() { r.Set(region, outside) }) {
+		t.Error("Rel.Set with a pointer outside the region did not panic")
+	}
+	var stray posix.Rel[uint64] // not inside the region
+	target, _ := posix.View[uint64](region, 8)
+	if !panics(func() { stray.Set(region, target) }) {
+		t.Error("Rel.Set on a Rel outside the region did not panic")
+	}
+	r.Set(region, target)
+	if r.Get(region) != target {
+		t.Error("Rel.Get did not return the pointer passed to Set")
+	}
+	r.Set(region, nil)
+	if !r.IsNil() {
+		t.Error("Rel.Set(nil) did not make it nil")
+	}
+}
+
+func mapAnon(t *testing.T, size int) []byte {
+	t.Helper()
+	b, _, err := posix.Mmap(nil, size, posix.PROT_RDWR, posix.MAP_ANON|posix.MAP_PRIVATE, -1, 0)
+	if err != nil {
+		t.Fatalf("Mmap: %v", err)
+	}
+	t.Cleanup(func() { _ = posix.Munmap(b) })
+	return b
+}
+
+func panics(fn func()) (panicked bool) {
+	defer func() { panicked = recover() != nil }()
+	fn()
+	return false
+}
+
+func equal(a, b []uint64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -51,6 +51,280 @@ func munmap(addr uintptr, length uintptr) (err error) {
 
 /*--------------------------------------------------------------------------------------------------------------------*/
 
+func mremap(oldaddr uintptr, oldlength uintptr, newlength uintptr, flags int, newaddr uintptr) (addr uintptr, err error) {
+	r0, _, e1 := _Syscall6(_SYS_MREMAP, oldaddr, oldlength, newlength, uintptr(flags), newaddr, 0)
+	addr = r0
+	if e1 != 0 {
+		err = errnoErr(e1)
+	}
+	return
+}
+
+/*--------------------------------------------------------------------------------------------------------------------*/
+
+func futex(addr *uint32, op int, val uint32, timeout *Timespec, addr2 *uint32, val3 uint32) (r int, err error) {
+	r0, _, e1 := _Syscall6(_SYS_FUTEX, uintptr(unsafe.Pointer(addr)), uintptr(op), uintptr(val), uintptr(unsafe.Pointer(timeout)), uintptr(unsafe.Pointer(addr2)), uintptr(val3))
+	r = int(r0)
+	if e1 != 0 {
+		err = errnoErr(e1)
+	}
+	return
+}
+
+/*--------------------------------------------------------------------------------------------------------------------*/
+
+func setRobustList(head unsafe.Pointer, length uintptr) (err error) {
+	_, _, e1 := _Syscall(_SYS_SET_ROBUST_LIST, uintptr(head), length, 0)
+	if e1 != 0 {
+		err = errnoErr(e1)
+	}
+	return
+}
+
+/*--------------------------------------------------------------------------------------------------------------------*/
+
+func mqOpen(name string, oflag int, mode uint32, attr *MqAttr) (mqd int, err error) {
+	var _p0 *byte
+	if _p0, err = syscall.BytePtrFromString(name); err != nil {
+		return -1, err
+	}
+	r0, _, e1 := _Syscall6(_SYS_MQ_OPEN, uintptr(unsafe.Pointer(_p0)), uintptr(oflag), uintptr(mode), uintptr(unsafe.Pointer(attr)), 0, 0)
+	mqd = int(r0)
+	if e1 != 0 {
+		err = errnoErr(e1)
+	}
+	return
+}
+
+func mqUnlink(name string) (err error) {
+	var _p0 *byte
+	if _p0, err = syscall.BytePtrFromString(name); err != nil {
+		return
+	}
+	_, _, e1 := _Syscall(_SYS_MQ_UNLINK, uintptr(unsafe.Pointer(_p0)), 0, 0)
+	if e1 != 0 {
+		err = errnoErr(e1)
+	}
+	return
+}
+
+func mqTimedsend(mqd int, msg []byte, prio uint, timeout *Timespec) (err error) {
+	var _p0 unsafe.Pointer
+	if len(msg) > 0 {
+		_p0 = unsafe.Pointer(&msg[0])
+	} else {
+		_p0 = unsafe.Pointer(&_zero)
+	}
+	_, _, e1 := _Syscall6(_SYS_MQ_TIMEDSEND, uintptr(mqd), uintptr(_p0), uintptr(len(msg)), uintptr(prio), uintptr(unsafe.Pointer(timeout)), 0)
+	if e1 != 0 {
+		err = errnoErr(e1)
+	}
+	return
+}
+
+func mqTimedreceive(mqd int, buf []byte, prio *uint32, timeout *Timespec) (n int, err error) {
+	var _p0 unsafe.Pointer
+	if len(buf) > 0 {
+		_p0 = unsafe.Pointer(&buf[0])
+	} else {
+		_p0 = unsafe.Pointer(&_zero)
+	}
+	r0, _, e1 := _Syscall6(_SYS_MQ_TIMEDRECEIVE, uintptr(mqd), uintptr(_p0), uintptr(len(buf)), uintptr(unsafe.Pointer(prio)), uintptr(unsafe.Pointer(timeout)), 0)
+	n = int(r0)
+	if e1 != 0 {
+		err = errnoErr(e1)
+	}
+	return
+}
+
+func mqNotify(mqd int, sev *Sigevent) (err error) {
+	_, _, e1 := _Syscall(_SYS_MQ_NOTIFY, uintptr(mqd), uintptr(unsafe.Pointer(sev)), 0)
+	if e1 != 0 {
+		err = errnoErr(e1)
+	}
+	return
+}
+
+func mqGetsetattr(mqd int, attr, old *MqAttr) (err error) {
+	_, _, e1 := _Syscall(_SYS_MQ_GETSETATTR, uintptr(mqd), uintptr(unsafe.Pointer(attr)), uintptr(unsafe.Pointer(old)))
+	if e1 != 0 {
+		err = errnoErr(e1)
+	}
+	return
+}
+
+/*--------------------------------------------------------------------------------------------------------------------*/
+
+func shmget(key int, size uintptr, flag int) (id int, err error) {
+	r0, _, e1 := _Syscall(_SYS_SHMGET, uintptr(key), size, uintptr(flag))
+	id = int(r0)
+	if e1 != 0 {
+		err = errnoErr(e1)
+	}
+	return
+}
+
+func shmat(id int, addr uintptr, flag int) (ret uintptr, err error) {
+	r0, _, e1 := _Syscall(_SYS_SHMAT, uintptr(id), addr, uintptr(flag))
+	ret = r0
+	if e1 != 0 {
+		err = errnoErr(e1)
+	}
+	return
+}
+
+func shmdt(addr uintptr) (err error) {
+	_, _, e1 := _Syscall(_SYS_SHMDT, addr, 0, 0)
+	if e1 != 0 {
+		err = errnoErr(e1)
+	}
+	return
+}
+
+func shmctl(id int, cmd int, buf *ShmidDs) (ret int, err error) {
+	r0, _, e1 := _Syscall(_SYS_SHMCTL, uintptr(id), uintptr(cmd), uintptr(unsafe.Pointer(buf)))
+	ret = int(r0)
+	if e1 != 0 {
+		err = errnoErr(e1)
+	}
+	return
+}
+
+/*--------------------------------------------------------------------------------------------------------------------*/
+
+func eventfd(initval uint, flags int) (fd int, err error) {
+	r0, _, e1 := _Syscall(_SYS_EVENTFD2, uintptr(initval), uintptr(flags), 0)
+	fd = int(r0)
+	if e1 != 0 {
+		err = errnoErr(e1)
+	}
+	return
+}
+
+func read(fd int, p []byte) (n int, err error) {
+	var _p0 unsafe.Pointer
+	if len(p) > 0 {
+		_p0 = unsafe.Pointer(&p[0])
+	} else {
+		_p0 = unsafe.Pointer(&_zero)
+	}
+	r0, _, e1 := _Syscall(_SYS_READ, uintptr(fd), uintptr(_p0), uintptr(len(p)))
+	n = int(r0)
+	if e1 != 0 {
+		err = errnoErr(e1)
+	}
+	return
+}
+
+func write(fd int, p []byte) (n int, err error) {
+	var _p0 unsafe.Pointer
+	if len(p) > 0 {
+		_p0 = unsafe.Pointer(&p[0])
+	} else {
+		_p0 = unsafe.Pointer(&_zero)
+	}
+	r0, _, e1 := _Syscall(_SYS_WRITE, uintptr(fd), uintptr(_p0), uintptr(len(p)))
+	n = int(r0)
+	if e1 != 0 {
+		err = errnoErr(e1)
+	}
+	return
+}
+
+/*--------------------------------------------------------------------------------------------------------------------*/
+
+func mincore(addr uintptr, length uintptr, vec []byte) (err error) {
+	var _p0 unsafe.Pointer
+	if len(vec) > 0 {
+		_p0 = unsafe.Pointer(&vec[0])
+	} else {
+		_p0 = unsafe.Pointer(&_zero)
+	}
+	_, _, e1 := _Syscall(_SYS_MINCORE, addr, length, uintptr(_p0))
+	if e1 != 0 {
+		err = errnoErr(e1)
+	}
+	return
+}
+
+/*--------------------------------------------------------------------------------------------------------------------*/
+
+func pidfdOpen(pid int, flags int) (fd int, err error) {
+	r0, _, e1 := _Syscall(_SYS_PIDFD_OPEN, uintptr(pid), uintptr(flags), 0)
+	fd = int(r0)
+	if e1 != 0 {
+		err = errnoErr(e1)
+	}
+	return
+}
+
+func processMadvise(pidfd int, iovecs []Iovec, advice int, flags int) (n int, err error) {
+	var _p0 unsafe.Pointer
+	if len(iovecs) > 0 {
+		_p0 = unsafe.Pointer(&iovecs[0])
+	} else {
+		_p0 = unsafe.Pointer(&_zero)
+	}
+	r0, _, e1 := _Syscall6(_SYS_PROCESS_MADVISE, uintptr(pidfd), uintptr(_p0), uintptr(len(iovecs)), uintptr(advice), uintptr(flags), 0)
+	n = int(r0)
+	if e1 != 0 {
+		err = errnoErr(e1)
+	}
+	return
+}
+
+func processMrelease(pidfd int, flags int) (err error) {
+	_, _, e1 := _Syscall(_SYS_PROCESS_MRELEASE, uintptr(pidfd), uintptr(flags), 0)
+	if e1 != 0 {
+		err = errnoErr(e1)
+	}
+	return
+}
+
+func processVMReadv(pid int, local []iovec, remote []RemoteIovec, flags int) (n int, err error) {
+	var _p0 unsafe.Pointer
+	if len(local) > 0 {
+		_p0 = unsafe.Pointer(&local[0])
+	} else {
+		_p0 = unsafe.Pointer(&_zero)
+	}
+	var _p1 unsafe.Pointer
+	if len(remote) > 0 {
+		_p1 = unsafe.Pointer(&remote[0])
+	} else {
+		_p1 = unsafe.Pointer(&_zero)
+	}
+	r0, _, e1 := _Syscall6(_SYS_PROCESS_VM_READV, uintptr(pid), uintptr(_p0), uintptr(len(local)), uintptr(_p1), uintptr(len(remote)), uintptr(flags))
+	n = int(r0)
+	if e1 != 0 {
+		err = errnoErr(e1)
+	}
+	return
+}
+
+func processVMWritev(pid int, local []iovec, remote []RemoteIovec, flags int) (n int, err error) {
+	var _p0 unsafe.Pointer
+	if len(local) > 0 {
+		_p0 = unsafe.Pointer(&local[0])
+	} else {
+		_p0 = unsafe.Pointer(&_zero)
+	}
+	var _p1 unsafe.Pointer
+	if len(remote) > 0 {
+		_p1 = unsafe.Pointer(&remote[0])
+	} else {
+		_p1 = unsafe.Pointer(&_zero)
+	}
+	r0, _, e1 := _Syscall6(_SYS_PROCESS_VM_WRITEV, uintptr(pid), uintptr(_p0), uintptr(len(local)), uintptr(_p1), uintptr(len(remote)), uintptr(flags))
+	n = int(r0)
+	if e1 != 0 {
+		err = errnoErr(e1)
+	}
+	return
+}
+
+/*--------------------------------------------------------------------------------------------------------------------*/
+
 func madvise(b []byte, advice int) (err error) {
 	var _p0 unsafe.Pointer
 	if len(b) > 0 {
@@ -105,6 +379,22 @@ func mlockall(flags int) (err error) {
 }
 
 /*--------------------------------------------------------------------------------------------------------------------*/
+func mlock2(b []byte, flags int) (err error) {
+	var _p0 unsafe.Pointer
+	if len(b) > 0 {
+		_p0 = unsafe.Pointer(&b[0])
+	} else {
+		_p0 = unsafe.Pointer(&_zero)
+	}
+	_, _, e1 := _Syscall(_SYS_MLOCK2, uintptr(_p0), uintptr(len(b)), uintptr(flags))
+	if e1 != 0 {
+		err = errnoErr(e1)
+	}
+	return
+}
+
+/*--------------------------------------------------------------------------------------------------------------------*/
+
 func munlock(b []byte, size int) (err error) {
 	var _p0 unsafe.Pointer
 	if len(b) > 0 {
@@ -34,22 +34,46 @@ type Stat_t struct {
 //
 //goland:noinspection GoSnakeCaseUsage
 const (
-	_SYS_FTRUNCATE    = 77
-	_SYS_MEMFD_CREATE = 319
-	_SYS_MADVISE      = 28
-	_SYS_MMAP         = 9
-	_SYS_MUNMAP       = 11
-	_SYS_MPROTECT     = 10
-	_SYS_MLOCK        = 149
-	_SYS_MUNLOCK      = 150
-	_SYS_MLOCKALL     = 151
-	_SYS_MUNLOCKALL   = 152
-	_SYS_MSYNC        = 26
-	_SYS_CLOSE        = 3
-	_SYS_FCHOWN       = 93
-	_SYS_FSTAT        = 5
-	_SYS_FCHMOD       = 91
-	_SYS_FCNTL        = 72
-	_SYS_OPENAT       = 257
-	_SYS_UNLINKAT     = 263
+	_SYS_FTRUNCATE         = 77
+	_SYS_MEMFD_CREATE      = 319
+	_SYS_MADVISE           = 28
+	_SYS_MMAP              = 9
+	_SYS_MUNMAP            = 11
+	_SYS_MREMAP            = 25
+	_SYS_MPROTECT          = 10
+	_SYS_MLOCK             = 149
+	_SYS_MLOCK2            = 325
+	_SYS_MUNLOCK           = 150
+	_SYS_MLOCKALL          = 151
+	_SYS_MUNLOCKALL        = 152
+	_SYS_MSYNC             = 26
+	_SYS_CLOSE             = 3
+	_SYS_FCHOWN            = 93
+	_SYS_FSTAT             = 5
+	_SYS_FCHMOD            = 91
+	_SYS_FCNTL             = 72
+	_SYS_OPENAT            = 257
+	_SYS_LINKAT            = 265
+	_SYS_UNLINKAT          = 263
+	_SYS_FUTEX             = 202
+	_SYS_MQ_OPEN           = 240
+	_SYS_MQ_UNLINK         = 241
+	_SYS_MQ_TIMEDSEND      = 242
+	_SYS_MQ_TIMEDRECEIVE   = 243
+	_SYS_MQ_NOTIFY         = 244
+	_SYS_MQ_GETSETATTR     = 245
+	_SYS_SHMGET            = 29
+	_SYS_SHMAT             = 30
+	_SYS_SHMDT             = 67
+	_SYS_SHMCTL            = 31
+	_SYS_EVENTFD2          = 290
+	_SYS_READ              = 0
+	_SYS_WRITE             = 1
+	_SYS_MINCORE           = 27
+	_SYS_PROCESS_VM_READV  = 310
+	_SYS_PROCESS_VM_WRITEV = 311
+	_SYS_PIDFD_OPEN        = 434
+	_SYS_PROCESS_MADVISE   = 440
+	_SYS_PROCESS_MRELEASE  = 448
+	_SYS_SET_ROBUST_LIST   = 273
 )
@@ -28,22 +28,46 @@ type Stat_t struct {
 //
 //goland:noinspection GoSnakeCaseUsage
 const (
-	_SYS_FCNTL        = 25
-	_SYS_UNLINKAT     = 35
-	_SYS_FTRUNCATE    = 46
-	_SYS_FCHMOD       = 52
-	_SYS_FCHOWN       = 55
-	_SYS_OPENAT       = 56
-	_SYS_CLOSE        = 57
-	_SYS_FSTAT        = 80
-	_SYS_MUNMAP       = 215
-	_SYS_MMAP         = 222
-	_SYS_MPROTECT     = 226
-	_SYS_MSYNC        = 227
-	_SYS_MLOCK        = 228
-	_SYS_MUNLOCK      = 229
-	_SYS_MLOCKALL     = 230
-	_SYS_MUNLOCKALL   = 231
-	_SYS_MADVISE      = 233
-	_SYS_MEMFD_CREATE = 279
+	_SYS_FCNTL             = 25
+	_SYS_LINKAT            = 37
+	_SYS_UNLINKAT          = 35
+	_SYS_FTRUNCATE         = 46
+	_SYS_FUTEX             = 98
+	_SYS_MQ_OPEN           = 180
+	_SYS_MQ_UNLINK         = 181
+	_SYS_MQ_TIMEDSEND      = 182
+	_SYS_MQ_TIMEDRECEIVE   = 183
+	_SYS_MQ_NOTIFY         = 184
+	_SYS_MQ_GETSETATTR     = 185
+	_SYS_SHMGET            = 194
+	_SYS_SHMAT             = 196
+	_SYS_SHMDT             = 197
+	_SYS_SHMCTL            = 195
+	_SYS_EVENTFD2          = 19
+	_SYS_READ              = 63
+	_SYS_WRITE             = 64
+	_SYS_MINCORE           = 232
+	_SYS_PROCESS_VM_READV  = 270
+	_SYS_PROCESS_VM_WRITEV = 271
+	_SYS_PIDFD_OPEN        = 434
+	_SYS_PROCESS_MADVISE   = 440
+	_SYS_PROCESS_MRELEASE  = 448
+	_SYS_SET_ROBUST_LIST   = 99
+	_SYS_FCHMOD            = 52
+	_SYS_FCHOWN            = 55
+	_SYS_OPENAT            = 56
+	_SYS_CLOSE             = 57
+	_SYS_FSTAT             = 80
+	_SYS_MUNMAP            = 215
+	_SYS_MREMAP            = 216
+	_SYS_MMAP              = 222
+	_SYS_MPROTECT          = 226
+	_SYS_MSYNC             = 227
+	_SYS_MLOCK             = 228
+	_SYS_MLOCK2            = 284
+	_SYS_MUNLOCK           = 229
+	_SYS_MLOCKALL          = 230
+	_SYS_MUNLOCKALL        = 231
+	_SYS_MADVISE           = 233
+	_SYS_MEMFD_CREATE      = 279
 )
@@ -0,0 +1,106 @@
+package posix
+
+import "unsafe"
+
+//goland:noinspection GoSnakeCaseUsage
+const (
+	PIDFD_NONBLOCK = O_NONBLOCK // PidfdOpen: a pidfd that does not block waiting for the process
+
+	UIO_MAXIOV = 1024 // the most Iovecs one call takes
+)
+
+// Iovec is struct iovec: a range of memory, given by address so that it can
+// describe another process's memory as well as this one's.
+type Iovec struct {
+	Base uintptr
+	Len  uint64
+}
+
+// IovecOf returns the Iovec covering b. A region mapped at the same address
+// in several processes, with MmapAt or through a published base, has the
+// same Iovec in all of them.
+func IovecOf(b []byte) Iovec {
+	if len(b) == 0 {
+		return Iovec{}
+	}
+	return Iovec{Base: uintptr(unsafe.Pointer(&b[0])), Len: uint64(len(b))}
+}
+
+// RemoteIovec is a range of another process's memory, given by its address
+// there.
+type RemoteIovec = Iovec
+
+// iovec is struct iovec for this process's own buffers: Base is a pointer, so
+// the buffers stay put and alive while the kernel copies to or from them.
+type iovec struct {
+	Base *byte
+	Len  uint64
+}
+
+// iovecs returns the iovecs covering bufs, or EINVAL for more than UIO_MAXIOV.
+func iovecs(bufs [][]byte) ([]iovec, error) {
+	if len(bufs) > UIO_MAXIOV {
+		return nil, EINVAL
+	}
+	iov := make([]iovec, 0, len(bufs))
+	for _, b := range bufs {
+		if len(b) > 0 {
+			iov = append(iov, iovec{Base: &b[0], Len: uint64(len(b))})
+		}
+	}
+	return iov, nil
+}
+
+// PidfdOpen returns a pidfd for the process pid: a descriptor that keeps
+// referring to that process, and never to another that reuses its PID. It is
+// close-on-exec; flags is 0 or PIDFD_NONBLOCK.
+func PidfdOpen(pid int, flags int) (int, error) {
+	return pidfdOpen(pid, flags)
+}
+
+// ProcessMadvise gives advice about ranges of the memory of the process behind
+// pidfd, the way Madvise does for the calling process, and returns how many
+// bytes it applied to. Only advice that does not change what the process
+// sees is accepted: MADV_COLD, MADV_PAGEOUT, MADV_WILLNEED and MADV_COLLAPSE.
+// It needs the right to ptrace the process, and CAP_SYS_NICE. At most
+// UIO_MAXIOV ranges are taken at once.
+func ProcessMadvise(pidfd int, iovecs []Iovec, advice int) (int, error) {
+	if len(iovecs) > UIO_MAXIOV {
+		return 0, EINVAL
+	}
+	return processMadvise(pidfd, iovecs, advice, 0)
+}
+
+// ProcessMrelease frees the memory of the process behind pidfd, which must
+// have been killed, without waiting for it to finish exiting, so a supervisor
+// gets the memory back at once. It fails with EINVAL for a process that is
+// not being killed.
+func ProcessMrelease(pidfd int) error {
+	return processMrelease(pidfd, 0)
+}
+
+// ProcessVMRead copies the ranges remote of the memory of process pid into the
+// buffers local, filling each buffer in turn as if both sides were one
+// contiguous stream, and returns how many bytes it copied. The copy stops
+// short at the first remote range that is not mapped readable; it fails with
+// EFAULT only if nothing was copied. It needs the right to ptrace the
+// process, and at most UIO_MAXIOV ranges are taken on either side.
+func ProcessVMRead(pid int, local [][]byte, remote []RemoteIovec) (int, error) {
+	iov, err := iovecs(local)
+	if err != nil || len(remote) > UIO_MAXIOV {
+		return 0, EINVAL
+	}
+	return processVMReadv(pid, iov, remote, 0)
+}
+
+// ProcessVMWrite copies the buffers local into the ranges remote of the memory
+// of process pid, the reverse of ProcessVMRead, and returns how many bytes it
+// copied. It writes whatever the process has mapped writable, private or
+// shared, without it noticing.
+func ProcessVMWrite(pid int, local [][]byte, remote []RemoteIovec) (int, error) {
+	iov, err := iovecs(local)
+	if err != nil || len(remote) > UIO_MAXIOV {
+		return 0, EINVAL
+	}
+	return processVMWritev(pid, iov, remote, 0)
+}
@@ -0,0 +1,174 @@
+package posix_test
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+	"testing"
+	"time"
+	"unsafe"
+
+	"gopkg.in/ro-ag/posix.v1"
+)
+
+const (
+	processChildEnv = "POSIX_PROCESS_CHILD"
+	processAddr     = 0x37000000000 // where the child maps its region
+	processPages    = 64
+)
+
+// startProcessChild re-executes the test binary as a child that maps a region
+// at processAddr, writes pattern into it, and waits to be killed.
+func startProcessChild(t *testing.T, test string, pattern string) *exec.Cmd {
+	t.Helper()
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command(exe, "-test.run=^"+test+"$")
+	cmd.Env = append(os.Environ(), processChildEnv+"="+pattern)
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("start child: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	})
+	sc := bufio.NewScanner(out)
+	for sc.Scan() {
+		if sc.Text() == "ready" {
+			return cmd
+		}
+	}
+	t.Fatal("child exited without mapping its region")
+	return nil
+}
+
+// processChild is the body of the re-executed child.
+func processChild(t *testing.T, pattern string) {
+	b, err := posix.MmapAt(unsafe.Pointer(uintptr(processAddr)), processPages*posix.Getpagesize(),
+		posix.PROT_RDWR, posix.MAP_PRIVATE|posix.MAP_ANON, -1, 0)
+	if err != nil {
+		t.Fatalf("child MmapAt: %v", err)
+	}
+	for i := range b {
+		b[i] = pattern[i%len(pattern)]
+	}
+	fmt.Println("ready")
+	time.Sleep(time.Minute)
+	t.Fatal("child was not killed")
+}
+
+func TestProcessMadvise(t *testing.T) {
+	if pattern := os.Getenv(processChildEnv); pattern != "" {
+		processChild(t, pattern)
+		return
+	}
+	cmd := startProcessChild(t, "TestProcessMadvise", "cold")
+	pidfd, err := posix.PidfdOpen(cmd.Process.Pid, 0)
+	if err != nil {
+		t.Fatalf("PidfdOpen: %v", err)
+	}
+	defer func() { _ = posix.Close(pidfd) }()
+
+	size := processPages * posix.Getpagesize()
+	region := []posix.Iovec{{Base: processAddr, Len: uint64(size)}}
+	n, err := posix.ProcessMadvise(pidfd, region, posix.MADV_COLD)
+	if errors.Is(err, posix.EPERM) || errors.Is(err, syscall.ENOSYS) {
+		t.Skipf("process_madvise unavailable: %v", err)
+	}
+	if err != nil || n != size {
+		t.Fatalf("ProcessMadvise MADV_COLD = %d, %v, want %d", n, err, size)
+	}
+	if n, err := posix.ProcessMadvise(pidfd, region, posix.MADV_PAGEOUT); err != nil || n != size {
+		t.Errorf("ProcessMadvise MADV_PAGEOUT = %d, %v, want %d", n, err, size)
+	}
+	if _, err := posix.ProcessMadvise(pidfd, region, posix.MADV_DONTNEED); !errors.Is(err, posix.EINVAL) {
+		t.Errorf("ProcessMadvise MADV_DONTNEED = %v, want EINVAL", err)
+	}
+	if _, err := posix.ProcessMadvise(pidfd, make([]posix.Iovec, posix.UIO_MAXIOV+1), posix.MADV_COLD); !errors.Is(err, posix.EINVAL) {
+		t.Errorf("ProcessMadvise of UIO_MAXIOV+1 ranges = %v, want EINVAL", err)
+	}
+
+	if err := posix.ProcessMrelease(pidfd); !errors.Is(err, posix.EINVAL) {
+		t.Errorf("ProcessMrelease of a live process = %v, want EINVAL", err)
+	}
+	if err := cmd.Process.Signal(syscall.SIGKILL); err != nil {
+		t.Fatal(err)
+	}
+	// The child may finish exiting first, releasing its memory itself.
+	if err := posix.ProcessMrelease(pidfd); err != nil && !errors.Is(err, syscall.ESRCH) {
+		t.Errorf("ProcessMrelease of a killed process: %v", err)
+	}
+	_ = cmd.Wait()
+	if _, err := posix.PidfdOpen(cmd.Process.Pid, 0); !errors.Is(err, syscall.ESRCH) {
+		t.Errorf("PidfdOpen of a reaped process = %v, want ESRCH", err)
+	}
+}
+
+// TestProcessVM reads a child's private region the way a debugger would, then
+// patches it and reads the patch back.
+func TestProcessVM(t *testing.T) {
+	if pattern := os.Getenv(processChildEnv); pattern != "" {
+		processChild(t, pattern)
+		return
+	}
+	const pattern = "0123456789abcdef"
+	cmd := startProcessChild(t, "TestProcessVM", pattern)
+	pid := cmd.Process.Pid
+
+	// Two local buffers, filled from two remote ranges a page apart.
+	pg := posix.Getpagesize()
+	head, tail := make([]byte, 10), make([]byte, 30)
+	remote := []posix.RemoteIovec{{Base: processAddr + 3, Len: 20}, {Base: processAddr + uintptr(pg), Len: 20}}
+	n, err := posix.ProcessVMRead(pid, [][]byte{head, tail}, remote)
+	if errors.Is(err, posix.EPERM) || errors.Is(err, syscall.ENOSYS) {
+		t.Skipf("process_vm_readv unavailable: %v", err)
+	}
+	if err != nil || n != 40 {
+		t.Fatalf("ProcessVMRead = %d, %v, want 40", n, err)
+	}
+	if got, want := string(head)+string(tail), pattern[3:]+pattern[:7]+pattern+pattern[:4]; got != want {
+		t.Errorf("ProcessVMRead read %q, want %q", got, want)
+	}
+
+	patch := []posix.RemoteIovec{{Base: processAddr + 100, Len: 5}}
+	if n, err := posix.ProcessVMWrite(pid, [][]byte{[]byte("hello")}, patch); err != nil || n != 5 {
+		t.Fatalf("ProcessVMWrite = %d, %v, want 5", n, err)
+	}
+	back := make([]byte, 5)
+	if n, err := posix.ProcessVMRead(pid, [][]byte{back}, patch); err != nil || n != 5 || string(back) != "hello" {
+		t.Errorf("ProcessVMRead after the write = %d, %q, %v, want \"hello\"", n, back, err)
+	}
+
+	// A read running off the end of the region stops there.
+	size := processPages * pg
+	n, err = posix.ProcessVMRead(pid, [][]byte{make([]byte, 2*pg)},
+		[]posix.RemoteIovec{{Base: processAddr + uintptr(size-pg), Len: uint64(2 * pg)}})
+	if err != nil || n != pg {
+		t.Errorf("ProcessVMRead past the end = %d, %v, want %d", n, err, pg)
+	}
+	if _, err := posix.ProcessVMRead(pid, [][]byte{back}, []posix.RemoteIovec{{Base: processAddr + uintptr(size), Len: 5}}); !errors.Is(err, posix.EFAULT) {
+		t.Errorf("ProcessVMRead of unmapped memory = %v, want EFAULT", err)
+	}
+	if _, err := posix.ProcessVMRead(pid, nil, make([]posix.RemoteIovec, posix.UIO_MAXIOV+1)); !errors.Is(err, posix.EINVAL) {
+		t.Errorf("ProcessVMRead of UIO_MAXIOV+1 ranges = %v, want EINVAL", err)
+	}
+}
+
+func TestIovecOf(t *testing.T) {
+	b := make([]byte, 10)
+	if v := posix.IovecOf(b[2:7]); v.Base != uintptr(unsafe.Pointer(&b[2])) || v.Len != 5 {
+		t.Errorf("IovecOf = %+v, want the 5 bytes at %p", v, &b[2])
+	}
+	if v := posix.IovecOf(nil); v != (posix.Iovec{}) {
+		t.Errorf("IovecOf(nil) = %+v, want the zero Iovec", v)
+	}
+}
@@ -0,0 +1,239 @@
+//go:build darwin || linux
+
+package posix
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"sync/atomic"
+	"time"
+	"unsafe"
+)
+
+// ErrNoQueue is returned by OpenQueue for a region that does not hold a queue.
+var ErrNoQueue = errors.New("posix: not a queue")
+
+const (
+	queueMagic   = 0x4555455551584953 // "SIXQUEUE"
+	queueVersion = 1
+)
+
+// queueHeader is the layout of the start of a queue region, followed by the
+// slots. Enqueue and Dequeue, the positions claimed so far by producers and
+// consumers, each have a cache line of their own.
+type queueHeader struct {
+	Magic    atomic.Uint64
+	Version  uint32
+	SlotSize uint32
+	Capacity uint64
+	_        [40]byte
+	Enqueue  atomic.Uint64
+	_        [56]byte
+	Dequeue  atomic.Uint64
+	_        [56]byte
+}
+
+// queueSlot heads each slot. Seq says whose turn the slot is: a producer at
+// position p may fill it when Seq == p, a consumer at p may empty it when
+// Seq == p+1.
+type queueSlot struct {
+	Seq atomic.Uint64
+	Len uint32
+	_   uint32
+}
+
+const (
+	queueHeaderSize = int(unsafe.Sizeof(queueHeader{}))
+	queueSlotHeader = int(unsafe.Sizeof(queueSlot{}))
+)
+
+// Queue is a bounded multi-producer, multi-consumer queue of fixed-size
+// messages kept in a shared region, after Dmitry Vyukov's array queue: every
+// slot carries a sequence number, so producers and consumers claim slots with
+// one compare-and-swap each and never lock. Any number of processes that map
+// the same region may enqueue and dequeue concurrently.
+//
+// A process that dies between claiming a slot and finishing with it leaves the
+// slot claimed, and the queue stalls when it wraps around to it.
+//
+// A Queue does not own its region; unmap it once the Queue is no longer used.
+type Queue struct {
+	hdr   *queueHeader
+	slots []byte
+	mask  uint64
+	size  int // slot payload size
+	step  int // slot stride
+}
+
+// QueueSize returns the number of bytes a region needs to hold a queue of
+// capacity slots of slotSize bytes each.
+func QueueSize(capacity, slotSize int) int {
+	return queueHeaderSize + capacity*queueStride(slotSize)
+}
+
+func queueStride(slotSize int) int {
+	return (queueSlotHeader + slotSize + 7) &^ 7
+}
+
+// NewQueue lays out an empty queue of capacity slots, each holding a message of
+// up to slotSize bytes, at the start of region, which must be at least
+// QueueSize(capacity, slotSize) bytes. capacity must be a power of two.
+// Anything already in the region is overwritten.
+func NewQueue(region []byte, capacity, slotSize int) (*Queue, error) {
+	if capacity < 1 || capacity&(capacity-1) != 0 || slotSize < 1 || slotSize > 1<<31 || len(region) < QueueSize(capacity, slotSize) {
+		return nil, EINVAL
+	}
+	h, err := View[queueHeader](region, 0)
+	if err != nil {
+		return nil, err
+	}
+	h.Magic.Store(0)
+	h.Version = queueVersion
+	h.SlotSize = uint32(slotSize)
+	h.Capacity = uint64(capacity)
+	h.Enqueue.Store(0)
+	h.Dequeue.Store(0)
+	q := newQueue(region, h)
+	for i := range capacity {
+		q.slot(uint64(i)).Seq.Store(uint64(i))
+	}
+	h.Magic.Store(queueMagic)
+	return q, nil
+}
+
+// OpenQueue returns a handle on the queue NewQueue laid out at the start of
+// region, possibly in another process. It fails with ErrNoQueue if there is
+// none.
+func OpenQueue(region []byte) (*Queue, error) {
+	h, err := View[queueHeader](region, 0)
+	if err != nil {
+		return nil, err
+	}
+	if h.Magic.Load() != queueMagic {
+		return nil, ErrNoQueue
+	}
+	capacity, slotSize := h.Capacity, int(h.SlotSize)
+	if h.Version != queueVersion || capacity == 0 || capacity&(capacity-1) != 0 || capacity > uint64(len(region)) ||
+		slotSize == 0 || len(region) < QueueSize(int(capacity), slotSize) {
+		return nil, fmt.Errorf("%w: unsupported header (version %d)", ErrNoQueue, h.Version)
+	}
+	return newQueue(region, h), nil
+}
+
+func newQueue(region []byte, h *queueHeader) *Queue {
+	size := int(h.SlotSize)
+	step := queueStride(size)
+	return &Queue{
+		hdr:   h,
+		slots: region[queueHeaderSize : queueHeaderSize+int(h.Capacity)*step],
+		mask:  h.Capacity - 1,
+		size:  size,
+		step:  step,
+	}
+}
+
+func (q *Queue) slot(pos uint64) *queueSlot {
+	return (*queueSlot)(unsafe.Pointer(&q.slots[int(pos&q.mask)*q.step]))
+}
+
+func (q *Queue) payload(pos uint64) []byte {
+	at := int(pos&q.mask)*q.step + queueSlotHeader
+	return q.slots[at : at+q.size]
+}
+
+// Cap returns the number of slots.
+func (q *Queue) Cap() int { return int(q.mask + 1) }
+
+// SlotSize returns the largest message a slot holds.
+func (q *Queue) SlotSize() int { return q.size }
+
+// TryEnqueue copies msg into the next free slot. It fails with EAGAIN if the
+// queue is full, and with EINVAL if msg is longer than SlotSize.
+func (q *Queue) TryEnqueue(msg []byte) error {
+	if len(msg) > q.size {
+		return EINVAL
+	}
+	pos := q.hdr.Enqueue.Load()
+	for {
+		s := q.slot(pos)
+		switch d := int64(s.Seq.Load() - pos); {
+		case d == 0:
+			if !q.hdr.Enqueue.CompareAndSwap(pos, pos+1) {
+				pos = q.hdr.Enqueue.Load()
+				continue
+			}
+			s.Len = uint32(copy(q.payload(pos), msg))
+			s.Seq.Store(pos + 1)
+			return nil
+		case d < 0:
+			return EAGAIN
+		default:
+			pos = q.hdr.Enqueue.Load()
+		}
+	}
+}
+
+// TryDequeue copies the oldest message into buf and returns its length. It
+// fails with EAGAIN if the queue is empty, and with EINVAL if buf is shorter
+// than SlotSize (a claimed message cannot be put back).
+func (q *Queue) TryDequeue(buf []byte) (int, error) {
+	if len(buf) < q.size {
+		return 0, EINVAL
+	}
+	pos := q.hdr.Dequeue.Load()
+	for {
+		s := q.slot(pos)
+		switch d := int64(s.Seq.Load() - (pos + 1)); {
+		case d == 0:
+			if !q.hdr.Dequeue.CompareAndSwap(pos, pos+1) {
+				pos = q.hdr.Dequeue.Load()
+				continue
+			}
+			n := copy(buf, q.payload(pos)[:s.Len])
+			s.Seq.Store(pos + q.mask + 1)
+			return n, nil
+		case d < 0:
+			return 0, EAGAIN
+		default:
+			pos = q.hdr.Dequeue.Load()
+		}
+	}
+}
+
+// Enqueue is TryEnqueue that waits, with backoff, while the queue is full.
+func (q *Queue) Enqueue(msg []byte) error {
+	var b backoff
+	for {
+		if err := q.TryEnqueue(msg); err != EAGAIN {
+			return err
+		}
+		b.wait()
+	}
+}
+
+// Dequeue is TryDequeue that waits, with backoff, while the queue is empty.
+func (q *Queue) Dequeue(buf []byte) (int, error) {
+	var b backoff
+	for {
+		if n, err := q.TryDequeue(buf); err != EAGAIN {
+			return n, err
+		}
+		b.wait()
+	}
+}
+
+// backoff paces a retry loop on shared memory, where there is nothing to block
+// on: it spins briefly, then yields, then sleeps for up to a millisecond.
+type backoff struct{ n int }
+
+func (b *backoff) wait() {
+	switch {
+	case b.n < 16:
+	case b.n < 64:
+		runtime.Gosched()
+	default:
+		time.Sleep(min(time.Duration(b.n-63)*time.Microsecond, time.Millisecond))
+	}
+	b.n++
+}
@@ -0,0 +1,209 @@
+//go:build darwin || linux
+
+package posix_test
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"gopkg.in/ro-ag/posix.v1"
+)
+
+// TestQueueTry: messages come out in order, with their lengths, and a full or
+// empty queue reports EAGAIN instead of blocking.
+func TestQueueTry(t *testing.T) {
+	region := mapAnon(t, posix.QueueSize(4, 16))
+	q, err := posix.NewQueue(region, 4, 16)
+	if err != nil {
+		t.Fatalf("NewQueue: %v", err)
+	}
+	buf := make([]byte, q.SlotSize())
+	if _, err := q.TryDequeue(buf); !errors.Is(err, posix.EAGAIN) {
+		t.Errorf("TryDequeue on an empty queue = %v, want EAGAIN", err)
+	}
+	for round := range 3 { // go around the slot array more than once
+		for i := range q.Cap() {
+			if err := q.TryEnqueue([]byte(strings.Repeat("x", round+i))); err != nil {
+				t.Fatalf("TryEnqueue %d/%d: %v", round, i, err)
+			}
+		}
+		if err := q.TryEnqueue([]byte("full")); !errors.Is(err, posix.EAGAIN) {
+			t.Errorf("TryEnqueue on a full queue = %v, want EAGAIN", err)
+		}
+		for i := range q.Cap() {
+			if n, err := q.TryDequeue(buf); err != nil || n != round+i {
+				t.Errorf("TryDequeue %d/%d = %d, %v; want %d", round, i, n, err, round+i)
+			}
+		}
+	}
+
+	if err := q.TryEnqueue(make([]byte, 17)); !errors.Is(err, posix.EINVAL) {
+		t.Errorf("TryEnqueue of an oversized message = %v, want EINVAL", err)
+	}
+	if _, err := q.TryDequeue(make([]byte, 15)); !errors.Is(err, posix.EINVAL) {
+		t.Errorf("TryDequeue into a short buffer = %v, want EINVAL", err)
+	}
+	if _, err := posix.NewQueue(region, 3, 16); !errors.Is(err, posix.EINVAL) {
+		t.Errorf("NewQueue(capacity 3) = %v, want EINVAL", err)
+	}
+	if _, err := posix.NewQueue(region, 8, 16); !errors.Is(err, posix.EINVAL) {
+		t.Errorf("NewQueue into a short region = %v, want EINVAL", err)
+	}
+	if _, err := posix.OpenQueue(mapAnon(t, posix.Getpagesize())); !errors.Is(err, posix.ErrNoQueue) {
+		t.Errorf("OpenQueue of a blank region = %v, want ErrNoQueue", err)
+	}
+}
+
+const (
+	queueChildEnv  = "POSIX_QUEUE_CHILD"
+	queueProducers = 2
+	queueWorkers   = 3
+	queueMessages  = 20000 // per producer
+	queueStop      = ^uint64(0)
+)
+
+// TestQueueCrossProcess fans messages from producer processes out to worker
+// processes through one queue in a named shared-memory object. The test binary
+// re-executes itself for each of them, as example/roundtrip does. Workers check
+// that each producer's messages reach them in order and report what they got;
+// every message must be delivered exactly once.
+func TestQueueCrossProcess(t *testing.T) {
+	if role := os.Getenv(queueChildEnv); role != "" {
+		queueChild(t, role)
+		return
+	}
+	name := fmt.Sprintf("/posix-q-%d", os.Getpid())
+	fd, err := posix.ShmOpen(name, posix.O_RDWR|posix.O_CREAT|posix.O_EXCL, posix.S_IRUSR|posix.S_IWUSR)
+	if err != nil {
+		t.Fatalf("ShmOpen: %v", err)
+	}
+	defer func() { _ = posix.ShmUnlink(name) }()
+	defer func() { _ = posix.Close(fd) }()
+	size := posix.QueueSize(64, 16)
+	if err := posix.Ftruncate(fd, size); err != nil {
+		t.Fatalf("Ftruncate: %v", err)
+	}
+	region, _, err := posix.Mmap(nil, size, posix.PROT_RDWR, posix.MAP_SHARED, fd, 0)
+	if err != nil {
+		t.Fatalf("Mmap: %v", err)
+	}
+	defer func() { _ = posix.Munmap(region) }()
+	q, err := posix.NewQueue(region, 64, 16)
+	if err != nil {
+		t.Fatalf("NewQueue: %v", err)
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := func(role string) (*exec.Cmd, *strings.Builder) {
+		cmd := exec.Command(exe, "-test.run=^TestQueueCrossProcess$")
+		cmd.Env = append(os.Environ(), queueChildEnv+"="+role+":"+name)
+		out := new(strings.Builder)
+		cmd.Stdout, cmd.Stderr = out, out
+		if err := cmd.Start(); err != nil {
+			t.Fatalf("start %s: %v", role, err)
+		}
+		return cmd, out
+	}
+	var workers []*exec.Cmd
+	var results []*strings.Builder
+	for w := range queueWorkers {
+		cmd, out := start(fmt.Sprintf("worker%d", w))
+		workers, results = append(workers, cmd), append(results, out)
+	}
+	var producers []*exec.Cmd
+	var logs []*strings.Builder
+	for p := range queueProducers {
+		cmd, out := start(fmt.Sprintf("producer%d", p))
+		producers, logs = append(producers, cmd), append(logs, out)
+	}
+	for p, cmd := range producers {
+		if err := cmd.Wait(); err != nil {
+			t.Fatalf("producer %d: %v\n%s", p, err, logs[p])
+		}
+	}
+	for range queueWorkers {
+		if err := q.Enqueue(queueMessage(queueStop, 0)); err != nil {
+			t.Fatalf("Enqueue(stop): %v", err)
+		}
+	}
+
+	var count, sum uint64
+	for w, cmd := range workers {
+		if err := cmd.Wait(); err != nil {
+			t.Fatalf("worker %d: %v\n%s", w, err, results[w])
+		}
+		var c, s uint64
+		_, report, _ := strings.Cut(results[w].String(), "got ")
+		if _, err := fmt.Sscanf(report, "%d sum %d", &c, &s); err != nil {
+			t.Fatalf("worker %d output: %v\n%s", w, err, results[w])
+		}
+		count, sum = count+c, sum+s
+	}
+	const want = queueProducers * queueMessages
+	if count != want || sum != queueProducers*(queueMessages*(queueMessages-1)/2) {
+		t.Errorf("workers got %d messages summing to %d, want %d summing to %d",
+			count, sum, want, queueProducers*(queueMessages*(queueMessages-1)/2))
+	}
+}
+
+func queueMessage(producer, seq uint64) []byte {
+	var m [16]byte
+	binary.LittleEndian.PutUint64(m[:8], producer)
+	binary.LittleEndian.PutUint64(m[8:], seq)
+	return m[:]
+}
+
+// queueChild is the body of a re-executed producer or worker process.
+func queueChild(t *testing.T, env string) {
+	role, name, _ := strings.Cut(env, ":")
+	fd, err := posix.ShmOpen(name, posix.O_RDWR, 0)
+	if err != nil {
+		t.Fatalf("ShmOpen: %v", err)
+	}
+	region, _, err := posix.Mmap(nil, posix.QueueSize(64, 16), posix.PROT_RDWR, posix.MAP_SHARED, fd, 0)
+	if err != nil {
+		t.Fatalf("Mmap: %v", err)
+	}
+	q, err := posix.OpenQueue(region)
+	if err != nil {
+		t.Fatalf("OpenQueue: %v", err)
+	}
+
+	var id uint64
+	if _, err := fmt.Sscanf(role, "producer%d", &id); err == nil {
+		for seq := range uint64(queueMessages) {
+			if err := q.Enqueue(queueMessage(id, seq)); err != nil {
+				t.Fatalf("Enqueue: %v", err)
+			}
+		}
+		return
+	}
+
+	next := make([]uint64, queueProducers)
+	var count, sum uint64
+	buf := make([]byte, q.SlotSize())
+	for {
+		if _, err := q.Dequeue(buf); err != nil {
+			t.Fatalf("Dequeue: %v", err)
+		}
+		p, seq := binary.LittleEndian.Uint64(buf), binary.LittleEndian.Uint64(buf[8:])
+		if p == queueStop {
+			break
+		}
+		if p >= queueProducers || seq < next[p] {
+			t.Fatalf("message %d from producer %d out of order (expected at least %d)", seq, p, next[p])
+		}
+		next[p] = seq + 1
+		count++
+		sum += seq
+	}
+	fmt.Printf("got %d sum %d\n", count, sum)
+}
@@ -0,0 +1,192 @@
+//go:build darwin || linux
+
+package posix
+
+import (
+	"fmt"
+	"sync"
+	"unsafe"
+)
+
+// Reservation is a range of address space held with PROT_NONE and
+// MAP_NORESERVE: it costs no memory, but nothing else — not the Go runtime, not
+// another mapping — can be placed inside it. Pieces are then committed with
+// Commit as a shared object grows, each at a fixed offset, so structures that
+// hold absolute pointers can live at the same address in every process that
+// reserves the same range early (before the runtime's heap grows into it).
+//
+// The reservation and its commits are one entry in the mapping registry:
+// commits never overlap, Decommit returns a range to the reserved state, and
+// Release unmaps the whole range at once. A mapping moved into the range with
+// MremapFixed, or attached there with Shmat and SHM_REMAP, counts as a commit.
+// A Reservation is safe for concurrent use.
+type Reservation struct {
+	mu   sync.Mutex
+	data []byte
+}
+
+// Reserve reserves size bytes of address space. A nil address lets the kernel
+// choose; otherwise the range must start exactly at address and, as with
+// MmapAt, an address that is already in use fails with ErrAddressInUse.
+func Reserve(address unsafe.Pointer, size int) (*Reservation, error) {
+	const flags = MAP_ANON | MAP_PRIVATE | MAP_NORESERVE
+	var data []byte
+	var err error
+	if address == nil {
+		data, _, err = Mmap(nil, size, PROT_NONE, flags, -1, 0)
+	} else {
+		data, err = MmapAt(address, size, PROT_NONE, flags, -1, 0)
+	}
+	if err != nil {
+		return nil, err
+	}
+	mapper.reserve(data)
+	return &Reservation{data: data}, nil
+}
+
+// Addr returns the start of the reserved range, or 0 once it is released.
+func (r *Reservation) Addr() uintptr {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.data == nil {
+		return 0
+	}
+	return uintptr(unsafe.Pointer(&r.data[0]))
+}
+
+// Len returns the size of the reserved range, or 0 once it is released.
+func (r *Reservation) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.data)
+}
+
+// Commit maps length bytes at offset off of the reservation with MAP_FIXED.
+// With fd >= 0 the bytes come from fd at fileOff through a MAP_SHARED mapping;
+// with fd < 0 they are private anonymous memory. off and length must be page
+// multiples inside the reservation, and the range must not overlap an earlier
+// commit (that fails with ErrAddressInUse rather than replacing it).
+//
+// The returned slice belongs to the reservation: it is not passed to Munmap,
+// but given back with Decommit or Release.
+func (r *Reservation) Commit(off, length int, fd int, fileOff int64, prot int) ([]byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.data == nil {
+		return nil, ErrClosed
+	}
+	flags := MAP_SHARED
+	if fd < 0 {
+		flags = MAP_ANON | MAP_PRIVATE
+	}
+	if err := sealCheckMmap(fd, prot, flags); err != nil {
+		return nil, err
+	}
+	return mapper.commit(r.data, off, length, prot, flags, fd, fileOff)
+}
+
+// Decommit returns [off, off+length) of the reservation to the reserved
+// (PROT_NONE) state, discarding the commits inside it. A commit may not
+// straddle the range boundary.
+func (r *Reservation) Decommit(off, length int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.data == nil {
+		return ErrClosed
+	}
+	return mapper.decommit(r.data, off, length)
+}
+
+// Release unmaps the whole reserved range, commits included. A second Release
+// returns ErrClosed.
+func (r *Reservation) Release() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.data == nil {
+		return ErrClosed
+	}
+	if err := Munmap(r.data); err != nil {
+		return err
+	}
+	r.data = nil
+	return nil
+}
+
+// reserve marks the entry of data as a reservation, one that commits can be
+// laid over.
+func (m *mmapper) reserve(data []byte) {
+	m.Lock()
+	defer m.Unlock()
+	if mp, ok := m.active[&data[0]]; ok {
+		mp.commits = make(map[uintptr]mapping)
+		m.active[&data[0]] = mp
+	}
+}
+
+// reserved returns the registry entry of the reservation res and validates a
+// page-aligned [off, off+length) inside it. The caller holds the lock.
+func (m *mmapper) reserved(res []byte, off, length int) (mapping, error) {
+	mp, ok := m.active[&res[0]]
+	if !ok || len(mp.data) != len(res) || mp.commits == nil {
+		return mapping{}, EINVAL
+	}
+	pg := Getpagesize()
+	if off < 0 || length <= 0 || off%pg != 0 || length%pg != 0 || length > len(res)-off {
+		return mapping{}, EINVAL
+	}
+	return mp, nil
+}
+
+func (m *mmapper) commit(res []byte, off, length int, prot, flags, fd int, fileOff int64) ([]byte, error) {
+	m.Lock()
+	defer m.Unlock()
+	mp, err := m.reserved(res, off, length)
+	if err != nil {
+		return nil, err
+	}
+	for o, c := range mp.commits {
+		if int(o) < off+length && off < int(o)+len(c.data) {
+			return nil, fmt.Errorf("%w: commit [%#x, %#x) overlaps [%#x, %#x)", ErrAddressInUse, off, off+length, o, int(o)+len(c.data))
+		}
+	}
+
+	// MAP_FIXED puts the commit exactly at &res[off], so the slice is built
+	// from the reservation rather than from the address mmap returns.
+	if _, err := m.mmap(uintptr(unsafe.Pointer(&res[off])), uintptr(length), prot, flags|MAP_FIXED, fd, fileOff); err != nil {
+		return nil, err
+	}
+	b := unsafe.Slice(&res[off], length)
+	mp.commits[uintptr(off)] = mapping{data: b, fd: fd, prot: prot}
+	return b, nil
+}
+
+func (m *mmapper) decommit(res []byte, off, length int) error {
+	m.Lock()
+	defer m.Unlock()
+	mp, err := m.reserved(res, off, length)
+	if err != nil {
+		return err
+	}
+	var inside []uintptr
+	for o, c := range mp.commits {
+		start, end := int(o), int(o)+len(c.data)
+		if start >= off+length || end <= off {
+			continue
+		}
+		if start < off || end > off+length {
+			return EINVAL
+		}
+		inside = append(inside, o)
+	}
+
+	// Map fresh reserved memory over the range: that both drops the commits
+	// and keeps the address space held.
+	want := uintptr(unsafe.Pointer(&res[off]))
+	if _, err := m.mmap(want, uintptr(length), PROT_NONE, MAP_ANON|MAP_PRIVATE|MAP_NORESERVE|MAP_FIXED, -1, 0); err != nil {
+		return err
+	}
+	for _, o := range inside {
+		delete(mp.commits, o)
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+//go:build darwin || linux
+
+package posix_test
+
+import (
+	"errors"
+	"testing"
+	"unsafe"
+
+	"gopkg.in/ro-ag/posix.v1"
+)
+
+// TestReservationCommit walks a reservation through its lifecycle: reserved
+// pages fault, committed pages are backed by the object, overlapping commits
+// are refused, a decommitted page faults again, and Release frees the lot.
+func TestReservationCommit(t *testing.T) {
+	pg := posix.Getpagesize()
+	fd, err := posix.MemfdCreate("reserve", posix.MFD_ALLOW_SEALING)
+	if err != nil {
+		t.Fatalf("MemfdCreate: %v", err)
+	}
+	defer func() { _ = posix.Close(fd) }()
+	if err := posix.Ftruncate(fd, 2*pg); err != nil {
+		t.Fatalf("Ftruncate: %v", err)
+	}
+
+	r, err := posix.Reserve(nil, 16*pg)
+	if err != nil {
+		t.Fatalf("Reserve: %v", err)
+	}
+	if r.Len() != 16*pg || r.Addr() == 0 {
+		t.Fatalf("Len/Addr = %d/%#x, want %d/non-zero", r.Len(), r.Addr(), 16*pg)
+	}
+	whole := unsafe.Slice((*byte)(unsafe.Pointer(r.Addr())), r.Len())
+	if !faults(func() { protectSink = whole[0] }) {
+		t.Error("reading a reserved, uncommitted page did not fault")
+	}
+
+	shared, err := r.Commit(0, 2*pg, fd, 0, posix.PROT_RDWR)
+	if err != nil {
+		t.Fatalf("Commit (shared): %v", err)
+	}
+	if uintptr(unsafe.Pointer(&shared[0])) != r.Addr() {
+		t.Errorf("Commit at offset 0 landed at %p, want %#x", &shared[0], r.Addr())
+	}
+	shared[pg] = 0x42
+	if whole[pg] != 0x42 {
+		t.Error("committed page not visible through the reserved range")
+	}
+	anon, err := r.Commit(4*pg, pg, -1, 0, posix.PROT_RDWR)
+	if err != nil {
+		t.Fatalf("Commit (anonymous): %v", err)
+	}
+	anon[0] = 1
+
+	// The registry knows about the writable commit of fd.
+	if err := posix.AddSeals(fd, posix.F_SEAL_WRITE); err == nil {
+		t.Error("AddSeals(F_SEAL_WRITE) with a writable commit live: want error, got nil")
+	}
+
+	if _, err := r.Commit(pg, 2*pg, -1, 0, posix.PROT_RDWR); !errors.Is(err, posix.ErrAddressInUse) {
+		t.Errorf("overlapping Commit = %v, want ErrAddressInUse", err)
+	}
+	if _, err := r.Commit(15*pg, 2*pg, -1, 0, posix.PROT_RDWR); !errors.Is(err, posix.EINVAL) {
+		t.Errorf("Commit past the end = %v, want EINVAL", err)
+	}
+	if err := r.Decommit(pg, pg); !errors.Is(err, posix.EINVAL) {
+		t.Errorf("Decommit splitting a commit = %v, want EINVAL", err)
+	}
+
+	if err := r.Decommit(4*pg, 2*pg); err != nil {
+		t.Fatalf("Decommit: %v", err)
+	}
+	if !faults(func() { protectSink = whole[4*pg] }) {
+		t.Error("reading a decommitted page did not fault")
+	}
+	if _, err := r.Commit(4*pg, pg, -1, 0, posix.PROT_RDWR); err != nil {
+		t.Errorf("re-Commit after Decommit: %v", err)
+	}
+
+	if err := r.Release(); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	if err := r.Release(); !errors.Is(err, posix.ErrClosed) {
+		t.Errorf("second Release = %v, want ErrClosed", err)
+	}
+	if err := posix.Munmap(shared); err == nil {
+		t.Error("Munmap of a released commit: want error, got nil")
+	}
+	if err := posix.AddSeals(fd, posix.F_SEAL_WRITE); err != nil {
+		t.Errorf("AddSeals(F_SEAL_WRITE) after Release: %v", err)
+	}
+}
+
+// TestReserveFixed: a reservation at a chosen address is exact, and a second
+// one over it is refused instead of replacing it.
+func TestReserveFixed(t *testing.T) {
+	const want = 0x34000000000
+	pg := posix.Getpagesize()
+	r, err := posix.Reserve(unsafe.Pointer(uintptr(want)), 8*pg)
+	if err != nil {
+		t.Fatalf("Reserve: %v", err)
+	}
+	defer func() { _ = r.Release() }()
+	if r.Addr() != want {
+		t.Errorf("Addr = %#x, want %#x", r.Addr(), uintptr(want))
+	}
+	if _, err := posix.Reserve(unsafe.Pointer(uintptr(want+pg)), pg); !errors.Is(err, posix.ErrAddressInUse) {
+		t.Errorf("Reserve over a reservation = %v, want ErrAddressInUse", err)
+	}
+}
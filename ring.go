@@ -0,0 +1,260 @@
+//go:build darwin || linux
+
+package posix
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"unsafe"
+)
+
+// ErrNoRing is returned by OpenRing for an object that does not hold a ring.
+var ErrNoRing = errors.New("posix: not a ring")
+
+const (
+	ringMagic   = 0x474e495258584953 // "SIXXRING"
+	ringVersion = 1
+)
+
+// ringHeader is the layout of the first page of a ring object. Head is written
+// only by the consumer and Tail only by the producer; each has a cache line of
+// its own so that the two sides do not contend for one.
+type ringHeader struct {
+	Magic    atomic.Uint64
+	Version  uint32
+	_        uint32
+	Capacity uint64
+	_        [40]byte
+	Head     atomic.Uint64 // bytes consumed so far
+	_        [56]byte
+	Tail     atomic.Uint64 // bytes produced so far
+	_        [56]byte
+}
+
+// Ring is a single-producer, single-consumer byte ring kept in a shared object:
+// one header page, then capacity bytes of data. The producer and consumer may
+// be in different processes, each with its own Ring on the same object; they
+// synchronize through atomic head and tail counters only, without locks or
+// system calls.
+//
+// The data pages are mapped twice, back to back, so a run of bytes that wraps
+// around the end of the ring is still contiguous in memory. That is what lets
+// Reserve and Peek hand out slices into the ring itself.
+//
+// One goroutine may produce (Write, Reserve, Commit) and one consume (Read,
+// Peek, Consume) at a time; a Ring is not safe for any other concurrent use.
+type Ring struct {
+	res      *Reservation
+	hdr      *ringHeader
+	data     []byte // both copies of the data pages
+	mask     uint64
+	reserved uint64 // bytes handed out by Reserve and not yet committed
+}
+
+// NewRing makes the object behind fd an empty ring of capacity bytes, sizing it
+// with Ftruncate, and returns a handle on it. capacity must be a power of two
+// and a multiple of the page size. The ring does not own fd; the caller closes
+// it.
+func NewRing(fd int, capacity int) (*Ring, error) {
+	pg := Getpagesize()
+	if capacity < pg || capacity&(capacity-1) != 0 {
+		return nil, EINVAL
+	}
+	if err := Ftruncate(fd, pg+capacity); err != nil {
+		return nil, err
+	}
+	r, err := mapRing(fd, capacity)
+	if err != nil {
+		return nil, err
+	}
+	r.hdr.Version = ringVersion
+	r.hdr.Capacity = uint64(capacity)
+	r.hdr.Head.Store(0)
+	r.hdr.Tail.Store(0)
+	r.hdr.Magic.Store(ringMagic)
+	return r, nil
+}
+
+// OpenRing returns a handle on the ring NewRing made in the object behind fd,
+// possibly in another process. It fails with ErrNoRing if there is none. The
+// ring does not own fd; the caller closes it.
+func OpenRing(fd int) (*Ring, error) {
+	pg := Getpagesize()
+	var st Stat_t
+	if err := Fstat(fd, &st); err != nil {
+		return nil, err
+	}
+	if int(st.Size) < pg {
+		return nil, ErrNoRing
+	}
+	hdr, _, err := Mmap(nil, pg, PROT_READ, MAP_SHARED, fd, 0)
+	if err != nil {
+		return nil, err
+	}
+	h := (*ringHeader)(unsafe.Pointer(&hdr[0]))
+	magic, version, capacity := h.Magic.Load(), h.Version, h.Capacity
+	_ = Munmap(hdr)
+	if magic != ringMagic {
+		return nil, ErrNoRing
+	}
+	if version != ringVersion || capacity < uint64(pg) || capacity&(capacity-1) != 0 || int64(pg)+int64(capacity) > st.Size {
+		return nil, fmt.Errorf("%w: unsupported header (version %d, capacity %d)", ErrNoRing, version, capacity)
+	}
+	return mapRing(fd, int(capacity))
+}
+
+// mapRing reserves room for the header and two copies of the data, and commits
+// the header page and the data pages, twice, from fd.
+func mapRing(fd int, capacity int) (*Ring, error) {
+	pg := Getpagesize()
+	res, err := Reserve(nil, pg+2*capacity)
+	if err != nil {
+		return nil, err
+	}
+	hdr, err := res.Commit(0, pg, fd, 0, PROT_RDWR)
+	if err == nil {
+		_, err = res.Commit(pg, capacity, fd, int64(pg), PROT_RDWR)
+	}
+	if err == nil {
+		_, err = res.Commit(pg+capacity, capacity, fd, int64(pg), PROT_RDWR)
+	}
+	if err != nil {
+		_ = res.Release()
+		return nil, err
+	}
+	return &Ring{
+		res:  res,
+		hdr:  (*ringHeader)(unsafe.Pointer(&hdr[0])),
+		data: unsafe.Slice((*byte)(unsafe.Add(unsafe.Pointer(&hdr[0]), pg)), 2*capacity),
+		mask: uint64(capacity) - 1,
+	}, nil
+}
+
+// Cap returns the capacity of the ring in bytes.
+func (r *Ring) Cap() int { return int(r.mask + 1) }
+
+// Len returns the number of bytes written and not yet consumed, or 0 once the
+// ring is closed.
+func (r *Ring) Len() int {
+	if r.hdr == nil {
+		return 0
+	}
+	tail := r.hdr.Tail.Load()
+	return int(tail - r.hdr.Head.Load())
+}
+
+// Write copies as much of p as fits into the ring. If not all of it fits it
+// returns the number of bytes written and EAGAIN.
+func (r *Ring) Write(p []byte) (int, error) {
+	free, err := r.Reserve(0)
+	if err != nil {
+		return 0, err
+	}
+	n := copy(free, p)
+	r.publish(uint64(n))
+	if n < len(p) {
+		return n, EAGAIN
+	}
+	return n, nil
+}
+
+// Read copies up to len(p) bytes out of the ring. It fails with EAGAIN if the
+// ring is empty.
+func (r *Ring) Read(p []byte) (int, error) {
+	if r.hdr == nil {
+		return 0, ErrClosed
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+	avail := r.Peek()
+	if len(avail) == 0 {
+		return 0, EAGAIN
+	}
+	n := copy(p, avail)
+	return n, r.Consume(n)
+}
+
+// Reserve returns n contiguous bytes of free space at the producer's end of
+// the ring, to be filled in place and published with Commit. n == 0 returns all
+// the free space there is. It fails with EAGAIN if fewer than n bytes are free,
+// and with EINVAL if n exceeds the capacity.
+func (r *Ring) Reserve(n int) ([]byte, error) {
+	if r.hdr == nil {
+		return nil, ErrClosed
+	}
+	if n < 0 || n > r.Cap() {
+		return nil, EINVAL
+	}
+	tail := r.hdr.Tail.Load()
+	free := r.mask + 1 - (tail - r.hdr.Head.Load())
+	if uint64(n) > free {
+		return nil, EAGAIN
+	}
+	if n == 0 {
+		n = int(free)
+	}
+	at := tail & r.mask
+	r.reserved = uint64(n)
+	return r.data[at : at+uint64(n) : at+uint64(n)], nil
+}
+
+// Commit publishes the first n bytes of the space returned by the last Reserve
+// to the consumer. n may be less than was reserved; the rest is given back.
+func (r *Ring) Commit(n int) error {
+	if r.hdr == nil {
+		return ErrClosed
+	}
+	if n < 0 || uint64(n) > r.reserved {
+		return EINVAL
+	}
+	r.publish(uint64(n))
+	return nil
+}
+
+func (r *Ring) publish(n uint64) {
+	r.reserved = 0
+	if n > 0 {
+		r.hdr.Tail.Add(n)
+	}
+}
+
+// Peek returns every byte written and not yet consumed, as one contiguous slice
+// into the ring. The bytes stay in the ring until Consume. It returns nil once
+// the ring is closed.
+func (r *Ring) Peek() []byte {
+	if r.hdr == nil {
+		return nil
+	}
+	head := r.hdr.Head.Load()
+	n := r.hdr.Tail.Load() - head
+	at := head & r.mask
+	return r.data[at : at+n : at+n]
+}
+
+// Consume drops the first n bytes returned by Peek from the ring.
+func (r *Ring) Consume(n int) error {
+	if r.hdr == nil {
+		return ErrClosed
+	}
+	head := r.hdr.Head.Load()
+	if n < 0 || uint64(n) > r.hdr.Tail.Load()-head {
+		return EINVAL
+	}
+	r.hdr.Head.Store(head + uint64(n))
+	return nil
+}
+
+// Close unmaps the ring. Its contents stay in the object. Afterwards the
+// methods fail with ErrClosed, a second Close included.
+func (r *Ring) Close() error {
+	if r.hdr == nil {
+		return ErrClosed
+	}
+	if err := r.res.Release(); err != nil {
+		return err
+	}
+	r.hdr, r.data = nil, nil
+	return nil
+}
@@ -0,0 +1,179 @@
+//go:build darwin || linux
+
+package posix_test
+
+import (
+	"bytes"
+	"errors"
+	"hash/crc32"
+	"runtime"
+	"testing"
+
+	"gopkg.in/ro-ag/posix.v1"
+)
+
+func newRingPair(t *testing.T, capacity int) (prod, cons *posix.Ring) {
+	t.Helper()
+	fd, err := posix.MemfdCreate("ring", 0)
+	if err != nil {
+		t.Fatalf("MemfdCreate: %v", err)
+	}
+	t.Cleanup(func() { _ = posix.Close(fd) })
+	prod, err = posix.NewRing(fd, capacity)
+	if err != nil {
+		t.Fatalf("NewRing: %v", err)
+	}
+	t.Cleanup(func() { _ = prod.Close() })
+	cons, err = posix.OpenRing(fd)
+	if err != nil {
+		t.Fatalf("OpenRing: %v", err)
+	}
+	t.Cleanup(func() { _ = cons.Close() })
+	return prod, cons
+}
+
+// TestRingWrap: a frame that straddles the end of the ring is still one
+// contiguous slice for Reserve and Peek, and fullness and emptiness are
+// reported with EAGAIN.
+func TestRingWrap(t *testing.T) {
+	pg := posix.Getpagesize()
+	prod, cons := newRingPair(t, pg)
+	if prod.Cap() != pg || cons.Cap() != pg {
+		t.Fatalf("Cap = %d/%d, want %d", prod.Cap(), cons.Cap(), pg)
+	}
+
+	if _, err := cons.Read(make([]byte, 1)); !errors.Is(err, posix.EAGAIN) {
+		t.Errorf("Read from an empty ring = %v, want EAGAIN", err)
+	}
+	// Move both ends close to the end of the ring.
+	if n, err := prod.Write(make([]byte, pg-10)); err != nil || n != pg-10 {
+		t.Fatalf("Write = %d, %v", n, err)
+	}
+	if err := cons.Consume(pg - 10); err != nil {
+		t.Fatalf("Consume: %v", err)
+	}
+
+	frame := []byte("frame across the wrap point")
+	buf, err := prod.Reserve(len(frame))
+	if err != nil {
+		t.Fatalf("Reserve: %v", err)
+	}
+	copy(buf, frame)
+	if cons.Len() != 0 {
+		t.Errorf("Len before Commit = %d, want 0", cons.Len())
+	}
+	if err := prod.Commit(len(frame)); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+	if got := cons.Peek(); !bytes.Equal(got, frame) {
+		t.Errorf("Peek = %q, want %q", got, frame)
+	}
+	got := make([]byte, 64)
+	if n, err := cons.Read(got); err != nil || !bytes.Equal(got[:n], frame) {
+		t.Errorf("Read = %q, %v; want %q", got[:n], err, frame)
+	}
+
+	if n, err := prod.Write(make([]byte, pg+1)); !errors.Is(err, posix.EAGAIN) || n != pg {
+		t.Errorf("overfilling Write = %d, %v; want %d, EAGAIN", n, err, pg)
+	}
+	if _, err := prod.Reserve(1); !errors.Is(err, posix.EAGAIN) {
+		t.Errorf("Reserve on a full ring = %v, want EAGAIN", err)
+	}
+	if _, err := prod.Reserve(pg + 1); !errors.Is(err, posix.EINVAL) {
+		t.Errorf("Reserve past the capacity = %v, want EINVAL", err)
+	}
+	if err := prod.Commit(1); !errors.Is(err, posix.EINVAL) {
+		t.Errorf("Commit without Reserve = %v, want EINVAL", err)
+	}
+	if err := cons.Consume(pg + 1); !errors.Is(err, posix.EINVAL) {
+		t.Errorf("Consume past the data = %v, want EINVAL", err)
+	}
+}
+
+// TestRingStream pushes a megabyte of varying frames from a producer
+// goroutine to a consumer on another handle, and compares checksums.
+func TestRingStream(t *testing.T) {
+	prod, cons := newRingPair(t, 4*posix.Getpagesize())
+	const total = 1 << 20
+
+	sent := make(chan uint32, 1)
+	go func() {
+		h := crc32.NewIEEE()
+		frame := make([]byte, 1500)
+		for off := 0; off < total; {
+			n := min(100+off%1400, total-off)
+			for i := range n {
+				frame[i] = byte(off + i)
+			}
+			for p := frame[:n]; len(p) > 0; {
+				w, _ := prod.Write(p)
+				p = p[w:]
+				if w == 0 {
+					runtime.Gosched()
+				}
+			}
+			h.Write(frame[:n])
+			off += n
+		}
+		sent <- h.Sum32()
+	}()
+
+	h := crc32.NewIEEE()
+	for got := 0; got < total; {
+		p := cons.Peek()
+		if len(p) == 0 {
+			runtime.Gosched()
+			continue
+		}
+		h.Write(p)
+		got += len(p)
+		if err := cons.Consume(len(p)); err != nil {
+			t.Fatalf("Consume: %v", err)
+		}
+	}
+	if want := <-sent; h.Sum32() != want {
+		t.Errorf("checksum of received stream %#x, want %#x", h.Sum32(), want)
+	}
+}
+
+// TestRingArguments: capacity must be a power-of-two number of pages, and only
+// a ring object can be opened.
+func TestRingArguments(t *testing.T) {
+	fd, err := posix.MemfdCreate("ring", 0)
+	if err != nil {
+		t.Fatalf("MemfdCreate: %v", err)
+	}
+	defer func() { _ = posix.Close(fd) }()
+	pg := posix.Getpagesize()
+	for _, c := range []int{0, pg / 2, 3 * pg} {
+		if _, err := posix.NewRing(fd, c); !errors.Is(err, posix.EINVAL) {
+			t.Errorf("NewRing(%d) = %v, want EINVAL", c, err)
+		}
+	}
+	if err := posix.Ftruncate(fd, 2*pg); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := posix.OpenRing(fd); !errors.Is(err, posix.ErrNoRing) {
+		t.Errorf("OpenRing of a blank object = %v, want ErrNoRing", err)
+	}
+}
+
+// TestRingClose: a closed ring refuses use, and a second Close, with ErrClosed.
+func TestRingClose(t *testing.T) {
+	prod, _ := newRingPair(t, posix.Getpagesize())
+	if err := prod.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if err := prod.Close(); !errors.Is(err, posix.ErrClosed) {
+		t.Errorf("second Close = %v, want ErrClosed", err)
+	}
+	if _, err := prod.Write([]byte("late")); !errors.Is(err, posix.ErrClosed) {
+		t.Errorf("Write after Close = %v, want ErrClosed", err)
+	}
+	if _, err := prod.Read(make([]byte, 4)); !errors.Is(err, posix.ErrClosed) {
+		t.Errorf("Read after Close = %v, want ErrClosed", err)
+	}
+	if p := prod.Peek(); p != nil || prod.Len() != 0 {
+		t.Errorf("Peek/Len after Close = %q/%d, want nil/0", p, prod.Len())
+	}
+}
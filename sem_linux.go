@@ -0,0 +1,230 @@
+package posix
+
+import (
+	"math/rand/v2"
+	"strconv"
+	"sync/atomic"
+	"time"
+	"unsafe"
+)
+
+// SEM_VALUE_MAX is the largest value a semaphore can hold.
+//
+//goland:noinspection GoSnakeCaseUsage
+const SEM_VALUE_MAX = 0x7fffffff
+
+// semT is glibc's struct new_sem on 64-bit targets, which is what a sem_t
+// created by sem_open holds: the value in the low 32 bits of data, the number
+// of waiters in the high 32, and whether the futex is process-private. glibc
+// waits and wakes on the value half of data alone.
+type semT struct {
+	data    atomic.Uint64
+	private int32
+	_       int32
+	_       [16]byte // sem_t is 32 bytes
+}
+
+const (
+	semNwaitersShift = 32
+	semValueMask     = 1<<semNwaitersShift - 1
+	semShared        = FUTEX_PRIVATE_FLAG // glibc's FUTEX_SHARED, for semaphores shared between processes
+)
+
+// Sem is a named semaphore opened with SemOpen. It is the same file, and the
+// same layout, that glibc's sem_open uses, so Go and C processes can share a
+// semaphore by name.
+type Sem struct {
+	data []byte
+	sem  *semT
+}
+
+// SemOpen opens the named semaphore name, the way sem_open does. With O_CREAT
+// it is created, with the given mode and initial value, if it does not exist
+// yet; with O_CREAT|O_EXCL it must not exist. Other flags, such as O_RDWR,
+// are ignored, as sem_open ignores them. Names follow the ShmOpen rules.
+func SemOpen(name string, oflag int, mode uint32, value uint32) (*Sem, error) {
+	path, err := shmPath(name, "sem.")
+	if err != nil {
+		return nil, err
+	}
+	oflag &= O_CREAT | O_EXCL
+	if value > SEM_VALUE_MAX {
+		return nil, EINVAL
+	}
+	for {
+		if oflag&(O_CREAT|O_EXCL) != O_CREAT|O_EXCL {
+			fd, err := openat(_AT_FDCWD, path, O_RDWR|O_NOFOLLOW|O_CLOEXEC, 0)
+			if err == nil {
+				return semMap(fd)
+			}
+			if err != ENOENT || oflag&O_CREAT == 0 {
+				return nil, err
+			}
+		}
+		s, err := semCreate(path, mode, value)
+		if err == EEXIST && oflag&O_EXCL == 0 {
+			continue // lost a race with another creator: open theirs
+		}
+		return s, err
+	}
+}
+
+// semCreate initializes the semaphore in a temporary file and links it into
+// place, as glibc does, so that nobody ever opens a half-initialized one.
+func semCreate(path string, mode uint32, value uint32) (*Sem, error) {
+	var tmp string
+	var fd int
+	for {
+		tmp = prefix + "sem." + strconv.FormatUint(rand.Uint64(), 36)
+		var err error
+		fd, err = openat(_AT_FDCWD, tmp, O_RDWR|O_CREAT|O_EXCL|O_NOFOLLOW|O_CLOEXEC, mode)
+		if err == nil {
+			break
+		}
+		if err != EEXIST {
+			return nil, err
+		}
+	}
+	defer func() { _ = unlinkat(_AT_FDCWD, tmp, 0) }()
+	if err := Ftruncate(fd, int(unsafe.Sizeof(semT{}))); err != nil {
+		_ = Close(fd)
+		return nil, err
+	}
+	s, err := semMap(fd)
+	if err != nil {
+		return nil, err
+	}
+	s.sem.private = semShared
+	s.sem.data.Store(uint64(value))
+	if err := linkat(_AT_FDCWD, tmp, _AT_FDCWD, path, 0); err != nil {
+		_ = SemClose(s)
+		return nil, err
+	}
+	return s, nil
+}
+
+// semMap maps the semaphore in fd and closes fd, which the mapping outlives.
+func semMap(fd int) (*Sem, error) {
+	b, _, err := Mmap(nil, int(unsafe.Sizeof(semT{})), PROT_RDWR, MAP_SHARED, fd, 0)
+	_ = Close(fd)
+	if err != nil {
+		return nil, err
+	}
+	return &Sem{data: b, sem: (*semT)(unsafe.Pointer(&b[0]))}, nil
+}
+
+// SemClose unmaps s, the way sem_close does. The semaphore itself lives on
+// until it is unlinked. Afterwards the methods of s fail with ErrClosed, a
+// second SemClose included.
+func SemClose(s *Sem) error {
+	if s.sem == nil {
+		return ErrClosed
+	}
+	if err := Munmap(s.data); err != nil {
+		return err
+	}
+	s.data, s.sem = nil, nil
+	return nil
+}
+
+// SemUnlink removes the named semaphore. Processes that have it open keep
+// using it.
+func SemUnlink(name string) error {
+	path, err := shmPath(name, "sem.")
+	if err != nil {
+		return err
+	}
+	return unlinkat(_AT_FDCWD, path, 0)
+}
+
+// value returns the futex word glibc waits on: the low half of data.
+func (s *Sem) value() *uint32 {
+	return (*uint32)(unsafe.Pointer(&s.sem.data))
+}
+
+// TryWait decrements s if it is above zero, and fails with EAGAIN otherwise.
+func (s *Sem) TryWait() error {
+	if s.sem == nil {
+		return ErrClosed
+	}
+	d := s.sem.data.Load()
+	for d&semValueMask != 0 {
+		if s.sem.data.CompareAndSwap(d, d-1) {
+			return nil
+		}
+		d = s.sem.data.Load()
+	}
+	return EAGAIN
+}
+
+// Wait decrements s, waiting for it to rise above zero first if need be.
+func (s *Sem) Wait() error {
+	return s.wait(time.Time{})
+}
+
+// TimedWait is Wait with an absolute deadline, as in sem_timedwait. It fails
+// with ETIMEDOUT if s is still zero when the deadline passes.
+func (s *Sem) TimedWait(deadline time.Time) error {
+	if deadline.IsZero() {
+		return EINVAL
+	}
+	return s.wait(deadline)
+}
+
+// wait follows glibc's slow path: register as a waiter, so that Post knows to
+// wake someone, and sleep on the value while it is zero.
+func (s *Sem) wait(deadline time.Time) error {
+	if s.sem == nil {
+		return ErrClosed
+	}
+	if s.TryWait() == nil {
+		return nil
+	}
+	d := s.sem.data.Add(1 << semNwaitersShift)
+	for {
+		if d&semValueMask == 0 {
+			err := FutexWaitBitset(s.value(), 0, deadline, FUTEX_BITSET_MATCH_ANY)
+			if err == ETIMEDOUT {
+				s.sem.data.Add(^uint64(1<<semNwaitersShift - 1))
+				return err
+			}
+			d = s.sem.data.Load()
+			continue
+		}
+		// Take one and leave the waiters in a single step.
+		if s.sem.data.CompareAndSwap(d, d-1-1<<semNwaitersShift) {
+			return nil
+		}
+		d = s.sem.data.Load()
+	}
+}
+
+// Post increments s, waking one waiter if there are any. It fails with
+// EOVERFLOW if s is already at SEM_VALUE_MAX.
+func (s *Sem) Post() error {
+	if s.sem == nil {
+		return ErrClosed
+	}
+	d := s.sem.data.Load()
+	for {
+		if d&semValueMask == SEM_VALUE_MAX {
+			return EOVERFLOW
+		}
+		if s.sem.data.CompareAndSwap(d, d+1) {
+			break
+		}
+		d = s.sem.data.Load()
+	}
+	if d>>semNwaitersShift != 0 {
+		_, _ = FutexWake(s.value(), 1)
+	}
+	return nil
+}
+
+// GetValue returns the current value of s.
+func (s *Sem) GetValue() (int, error) {
+	if s.sem == nil {
+		return 0, ErrClosed
+	}
+	return int(s.sem.data.Load() & semValueMask), nil
+}
@@ -0,0 +1,196 @@
+package posix_test
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gopkg.in/ro-ag/posix.v1"
+)
+
+// semName returns a semaphore name unique to this test and removes the
+// semaphore when the test ends.
+func semName(t *testing.T) string {
+	t.Helper()
+	name := fmt.Sprintf("/posix-test-%s-%d", t.Name(), os.Getpid())
+	t.Cleanup(func() { _ = posix.SemUnlink(name) })
+	return name
+}
+
+func TestSemOpen(t *testing.T) {
+	name := semName(t)
+	if _, err := posix.SemOpen(name, 0, 0, 0); !errors.Is(err, posix.ENOENT) {
+		t.Fatalf("SemOpen of a missing semaphore = %v, want ENOENT", err)
+	}
+	if _, err := posix.SemOpen("/a/b", posix.O_CREAT, 0o600, 0); !errors.Is(err, posix.EINVAL) {
+		t.Errorf("SemOpen with a slash in the name = %v, want EINVAL", err)
+	}
+	if _, err := posix.SemOpen(name, posix.O_CREAT, 0o600, posix.SEM_VALUE_MAX+1); !errors.Is(err, posix.EINVAL) {
+		t.Errorf("SemOpen above SEM_VALUE_MAX = %v, want EINVAL", err)
+	}
+	s, err := posix.SemOpen(name, posix.O_CREAT|posix.O_EXCL, 0o600, 2)
+	if err != nil {
+		t.Fatalf("SemOpen: %v", err)
+	}
+	defer func() { _ = posix.SemClose(s) }()
+	if _, err := posix.SemOpen(name, posix.O_CREAT|posix.O_EXCL, 0o600, 0); !errors.Is(err, posix.EEXIST) {
+		t.Errorf("second exclusive SemOpen = %v, want EEXIST", err)
+	}
+	// Without O_EXCL the existing semaphore is opened and value is ignored, as
+	// are flags sem_open does not look at.
+	s2, err := posix.SemOpen(name, posix.O_CREAT|posix.O_RDWR, 0o600, 7)
+	if err != nil {
+		t.Fatalf("SemOpen existing: %v", err)
+	}
+	defer func() { _ = posix.SemClose(s2) }()
+
+	// glibc's layout: 32 bytes, the value in the low half of the first word,
+	// and FUTEX_SHARED in the int that follows.
+	raw, err := os.ReadFile("/dev/shm/sem." + name[1:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(raw) != 32 || binary.NativeEndian.Uint32(raw) != 2 || binary.NativeEndian.Uint32(raw[8:]) != 128 {
+		t.Errorf("semaphore file = %x, want glibc's new_sem with value 2", raw)
+	}
+
+	for range 2 {
+		if err := s2.TryWait(); err != nil {
+			t.Fatalf("TryWait: %v", err)
+		}
+	}
+	if err := s.TryWait(); !errors.Is(err, posix.EAGAIN) {
+		t.Errorf("TryWait at zero = %v, want EAGAIN", err)
+	}
+	start := time.Now()
+	if err := s.TimedWait(start.Add(20 * time.Millisecond)); !errors.Is(err, posix.ETIMEDOUT) {
+		t.Errorf("TimedWait at zero = %v, want ETIMEDOUT", err)
+	}
+	if d := time.Since(start); d < 20*time.Millisecond {
+		t.Errorf("TimedWait returned after %v, before its deadline", d)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- s.Wait() }()
+	time.Sleep(10 * time.Millisecond) // let the waiter block
+	if err := s2.Post(); err != nil {
+		t.Fatalf("Post: %v", err)
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Wait: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Wait not woken by Post")
+	}
+	if v, _ := s.GetValue(); v != 0 {
+		t.Errorf("GetValue = %d, want 0", v)
+	}
+
+	if err := posix.SemUnlink(name); err != nil {
+		t.Fatalf("SemUnlink: %v", err)
+	}
+	if err := s.Post(); err != nil {
+		t.Errorf("Post after SemUnlink: %v", err)
+	}
+	if v, _ := s2.GetValue(); v != 1 {
+		t.Errorf("GetValue after SemUnlink = %d, want 1", v)
+	}
+
+	if err := posix.SemClose(s2); err != nil {
+		t.Fatalf("SemClose: %v", err)
+	}
+	if err := posix.SemClose(s2); !errors.Is(err, posix.ErrClosed) {
+		t.Errorf("second SemClose = %v, want ErrClosed", err)
+	}
+	if err := s2.Wait(); !errors.Is(err, posix.ErrClosed) {
+		t.Errorf("Wait after SemClose = %v, want ErrClosed", err)
+	}
+}
+
+// semC posts the semaphore argv[1] argv[2] times, then waits on it once.
+const semC = `#include <fcntl.h>
+#include <semaphore.h>
+#include <stdio.h>
+#include <stdlib.h>
+
+int main(int argc, char **argv) {
+	sem_t *s = sem_open(argv[1], 0);
+	if (s == SEM_FAILED) { perror("sem_open"); return 1; }
+	for (int i = atoi(argv[2]); i > 0; i--)
+		if (sem_post(s) != 0) { perror("sem_post"); return 1; }
+	if (sem_wait(s) != 0) { perror("sem_wait"); return 1; }
+	return sem_close(s);
+}
+`
+
+// TestSemWithC shares a semaphore with a C program using glibc's sem_open.
+func TestSemWithC(t *testing.T) {
+	cc, err := exec.LookPath("cc")
+	if err != nil {
+		t.Skip("no C compiler")
+	}
+	dir := t.TempDir()
+	src := filepath.Join(dir, "sem.c")
+	if err := os.WriteFile(src, []byte(semC), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	prog := filepath.Join(dir, "sem")
+	if out, err := exec.Command(cc, "-o", prog, src, "-pthread").CombinedOutput(); err != nil {
+		t.Skipf("cannot build the C program: %v\n%s", err, out)
+	}
+
+	name := semName(t)
+	s, err := posix.SemOpen(name, posix.O_CREAT|posix.O_EXCL, 0o600, 0)
+	if err != nil {
+		t.Fatalf("SemOpen: %v", err)
+	}
+	defer func() { _ = posix.SemClose(s) }()
+
+	// The C program posts 3 times and takes one back; we take the other two,
+	// waiting on the C program's posts. Then the C program, holding nothing,
+	// waits for ours.
+	cmd := exec.Command(prog, name, "3")
+	cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	for range 2 {
+		if err := s.TimedWait(time.Now().Add(5 * time.Second)); err != nil {
+			t.Fatalf("TimedWait for the C program's posts: %v", err)
+		}
+	}
+	if err := cmd.Wait(); err != nil {
+		t.Fatalf("C program: %v", err)
+	}
+
+	cmd = exec.Command(prog, name, "0")
+	cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(20 * time.Millisecond) // let the C program block in sem_wait
+	if err := s.Post(); err != nil {
+		t.Fatalf("Post: %v", err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("C program: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		_ = cmd.Process.Kill()
+		t.Fatal("C program not woken by Post")
+	}
+	if v, _ := s.GetValue(); v != 0 {
+		t.Errorf("GetValue = %d, want 0", v)
+	}
+}
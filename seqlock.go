@@ -0,0 +1,94 @@
+//go:build darwin || linux
+
+package posix
+
+import (
+	"encoding/binary"
+	"reflect"
+	"sync/atomic"
+	"unsafe"
+)
+
+// SeqLock publishes snapshots of a T from writers to any number of readers,
+// in any processes, without readers ever taking a lock or writing to shared
+// memory: a reader copies the value and retries if a write overlapped the
+// copy, so readers never hold up a writer and never contend with each other.
+// It suits small, frequently read values such as the latest quote or a
+// configuration block.
+//
+// Lay a SeqLock over a shared mapping with View, which refuses a T that holds
+// Go pointers, strings, slices or other references; its zero value holds the
+// zero T. Store and Load panic with ErrNotShareable for such a T too, however
+// the SeqLock was made, since its words are copied without the write barriers
+// the collector relies on to see pointers. The value is copied in 8-byte
+// atomic words, so a T of any layout is never read torn.
+//
+// Writers exclude each other through the sequence counter. A writer that dies
+// in the middle of Store leaves the counter odd, and readers waiting forever.
+type SeqLock[T any] struct {
+	seq atomic.Uint64 // odd while a Store is in progress
+	v   T
+}
+
+// words returns the words holding s.v. The last may run into padding, but
+// never past the SeqLock, whose size is rounded up to its 8-byte alignment.
+func (s *SeqLock[T]) words() []atomic.Uint64 {
+	n := (unsafe.Sizeof(s.v) + 7) / 8
+	return unsafe.Slice((*atomic.Uint64)(unsafe.Pointer(&s.v)), n)
+}
+
+// Store publishes v. Concurrent Stores are serialized.
+func (s *SeqLock[T]) Store(v T) {
+	mustShare[T]()
+	var b backoff
+	seq := s.seq.Load()
+	for seq&1 != 0 || !s.seq.CompareAndSwap(seq, seq+1) {
+		b.wait()
+		seq = s.seq.Load()
+	}
+	src := unsafe.Slice((*byte)(unsafe.Pointer(&v)), unsafe.Sizeof(v))
+	words := s.words()
+	for i := range words {
+		var word [8]byte
+		copy(word[:], src[i*8:])
+		words[i].Store(binary.NativeEndian.Uint64(word[:]))
+	}
+	s.seq.Store(seq + 2)
+}
+
+// Load returns the most recently stored value. It retries, without blocking
+// any writer, while a Store is in progress.
+func (s *SeqLock[T]) Load() T {
+	mustShare[T]()
+	var v T
+	dst := unsafe.Slice((*byte)(unsafe.Pointer(&v)), unsafe.Sizeof(v))
+	var b backoff
+	for {
+		seq := s.seq.Load()
+		if seq&1 == 0 {
+			words := s.words()
+			for i := range words {
+				var word [8]byte
+				binary.NativeEndian.PutUint64(word[:], words[i].Load())
+				copy(dst[i*8:], word[:])
+			}
+			if s.seq.Load() == seq {
+				return v
+			}
+		}
+		b.wait()
+	}
+}
+
+// mustShare panics if T is not shareable. The check is cached per type.
+func mustShare[T any]() {
+	if err := shareable(reflect.TypeFor[T]()); err != nil {
+		panic(err)
+	}
+}
+
+// Version returns the number of Stores completed so far, letting a reader tell
+// cheaply whether there is anything new to Load.
+func (s *SeqLock[T]) Version() uint64 {
+	return s.seq.Load() / 2
+}
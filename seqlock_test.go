@@ -0,0 +1,141 @@
+//go:build darwin || linux
+
+package posix_test
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"gopkg.in/ro-ag/posix.v1"
+)
+
+// quote is an awkwardly sized snapshot: 29 bytes, not a whole number of words.
+type quote struct {
+	Bid, Ask, Seq uint64
+	Venue         [5]byte
+}
+
+func newQuoteLock(tb testing.TB) *posix.SeqLock[quote] {
+	tb.Helper()
+	b, _, err := posix.Mmap(nil, posix.Getpagesize(), posix.PROT_RDWR, posix.MAP_ANON|posix.MAP_SHARED, -1, 0)
+	if err != nil {
+		tb.Fatalf("Mmap: %v", err)
+	}
+	tb.Cleanup(func() { _ = posix.Munmap(b) })
+	s, err := posix.View[posix.SeqLock[quote]](b, 0)
+	if err != nil {
+		tb.Fatalf("View: %v", err)
+	}
+	return s
+}
+
+func quoteOf(n uint64) quote {
+	b := byte(n)
+	return quote{Bid: n, Ask: n, Seq: n, Venue: [5]byte{b, b, b, b, b}}
+}
+
+// TestSeqLockSnapshots: readers racing a writer only ever see whole snapshots,
+// never a mix of two.
+func TestSeqLockSnapshots(t *testing.T) {
+	s := newQuoteLock(t)
+	if got := s.Load(); got != (quote{}) || s.Version() != 0 {
+		t.Fatalf("zero SeqLock: Load = %+v, Version = %d", got, s.Version())
+	}
+
+	var stop atomic.Bool
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for !stop.Load() {
+				q := s.Load()
+				if q != quoteOf(q.Seq) {
+					t.Errorf("torn snapshot %+v", q)
+					return
+				}
+			}
+		}()
+	}
+	const stores = 20000
+	for n := range uint64(stores) {
+		s.Store(quoteOf(n + 1))
+	}
+	stop.Store(true)
+	wg.Wait()
+	if got := s.Load(); got != quoteOf(stores) || s.Version() != stores {
+		t.Errorf("after %d stores: Load = %+v, Version = %d", stores, got, s.Version())
+	}
+}
+
+// TestSeqLockShareable: the typed-view checks apply to the value type, and to
+// a SeqLock made without View as well.
+func TestSeqLockShareable(t *testing.T) {
+	b := make([]byte, 64)
+	if _, err := posix.View[posix.SeqLock[*int]](b, 0); !errors.Is(err, posix.ErrNotShareable) {
+		t.Errorf("View of a SeqLock of pointers = %v, want ErrNotShareable", err)
+	}
+	var s posix.SeqLock[*int]
+	if !panics(func() { s.Store(new(int)) }) {
+		t.Error("Store of a pointer into a heap SeqLock did not panic")
+	}
+	if !panics(func() { _ = s.Load() }) {
+		t.Error("Load from a heap SeqLock of pointers did not panic")
+	}
+}
+
+// BenchmarkSeqLockLoad measures an uncontended read: two atomic loads of the
+// counter around the copy, and no writes to shared memory.
+func BenchmarkSeqLockLoad(b *testing.B) {
+	s := newQuoteLock(b)
+	s.Store(quoteOf(1))
+	for b.Loop() {
+		_ = s.Load()
+	}
+}
+
+// BenchmarkSeqLockLoadParallel reads from every P at once. Readers share the
+// cache line without writing to it, so the cost per read stays flat as P
+// grows, where a reader lock would bounce the line between cores.
+func BenchmarkSeqLockLoadParallel(b *testing.B) {
+	s := newQuoteLock(b)
+	s.Store(quoteOf(1))
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			_ = s.Load()
+		}
+	})
+}
+
+// BenchmarkSeqLockLoadWithWriter reads while a writer stores continuously:
+// readers retry now and then, but never wait for a lock.
+func BenchmarkSeqLockLoadWithWriter(b *testing.B) {
+	s := newQuoteLock(b)
+	var stop atomic.Bool
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for n := uint64(1); !stop.Load(); n++ {
+			s.Store(quoteOf(n))
+		}
+	}()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			_ = s.Load()
+		}
+	})
+	stop.Store(true)
+	<-done
+}
+
+// BenchmarkSeqLockStore measures a write, which readers cannot delay.
+func BenchmarkSeqLockStore(b *testing.B) {
+	s := newQuoteLock(b)
+	var n uint64
+	for b.Loop() {
+		n++
+		s.Store(quoteOf(n))
+	}
+}
@@ -25,18 +25,34 @@ func shmUnlink(name string) (err error) {
 }
 
 func shmName(name string) (string, error) {
+	return shmPath(name, "")
+}
+
+// shmPath maps a POSIX IPC object name to its file under /dev/shm, the way
+// glibc does: kind ("sem." for semaphores) is prepended to the ipcName.
+func shmPath(name string, kind string) (string, error) {
+	name, err := ipcName(name, len(kind))
+	if err != nil {
+		return "", err
+	}
+	return prefix + kind + name, nil
+}
+
+// ipcName validates a POSIX IPC object name and drops its leading slashes.
+// extra is what the caller will prepend to it, which counts toward NAME_MAX.
+func ipcName(name string, extra int) (string, error) {
 
 	for len(name) != 0 && name[0] == '/' {
 		name = name[1:]
 	}
 
-	nameLen := len(name)
+	nameLen := extra + len(name)
 
-	if nameLen == 0 || nameLen >= syscall.NAME_MAX || strings.Contains(name, "/") {
+	if len(name) == 0 || nameLen >= syscall.NAME_MAX || strings.Contains(name, "/") {
 		return "", EINVAL
 	}
 
-	return prefix + name, nil
+	return name, nil
 }
 
 func openat(dirfd int, path string, flags int, mode uint32) (fd int, err error) {
@@ -66,6 +82,21 @@ func unlinkat(dirfd int, path string, flags int) (err error) {
 	return
 }
 
+func linkat(olddirfd int, oldpath string, newdirfd int, newpath string, flags int) (err error) {
+	var _p0, _p1 *byte
+	if _p0, err = syscall.BytePtrFromString(oldpath); err != nil {
+		return
+	}
+	if _p1, err = syscall.BytePtrFromString(newpath); err != nil {
+		return
+	}
+	_, _, e1 := _Syscall6(_SYS_LINKAT, uintptr(olddirfd), uintptr(unsafe.Pointer(_p0)), uintptr(newdirfd), uintptr(unsafe.Pointer(_p1)), uintptr(flags), 0)
+	if e1 != 0 {
+		err = errnoErr(e1)
+	}
+	return
+}
+
 //goland:noinspection GoSnakeCaseUsage
 const (
 	prefix    = "/dev/shm/"
@@ -0,0 +1,166 @@
+package posix
+
+import (
+	"math"
+	"sync/atomic"
+	"time"
+	"unsafe"
+)
+
+// The primitives in this file, like SharedMutex, live in shared memory: lay
+// them over a MAP_SHARED mapping with View and use the same one from every
+// process. Waiting is done in the kernel with futexes, never by spinning.
+// Unlike SharedMutex they are not robust: a process that dies inside one can
+// leave the others waiting.
+
+// futexWord returns the futex word behind an atomic.Uint32.
+func futexWord(v *atomic.Uint32) *uint32 {
+	return (*uint32)(unsafe.Pointer(v))
+}
+
+func futexWakeAll(v *atomic.Uint32) {
+	_, _ = FutexWake(futexWord(v), math.MaxInt32)
+}
+
+// SharedCond is a condition variable in shared memory, used with a
+// SharedMutex. Its zero value is ready to use.
+type SharedCond struct {
+	seq atomic.Uint32 // bumped by every Signal and Broadcast
+	_   uint32
+}
+
+// Wait unlocks m, waits for Signal or Broadcast, and locks m again before
+// returning. A negative timeout waits indefinitely; otherwise Wait returns
+// ETIMEDOUT, with m locked, once timeout has passed. As with sync.Cond, Wait
+// may also return spuriously, so callers wait in a loop on their condition. If
+// relocking m reports ErrOwnerDead, Wait returns that.
+func (c *SharedCond) Wait(m *SharedMutex, timeout time.Duration) error {
+	seq := c.seq.Load()
+	if err := m.Unlock(); err != nil {
+		return err
+	}
+	err := FutexWait(futexWord(&c.seq), seq, timeout)
+	if lerr := m.Lock(); lerr != nil {
+		return lerr
+	}
+	if err == ETIMEDOUT {
+		return err
+	}
+	return nil
+}
+
+// Signal wakes one process or goroutine waiting on c, if there is one.
+func (c *SharedCond) Signal() {
+	c.seq.Add(1)
+	_, _ = FutexWake(futexWord(&c.seq), 1)
+}
+
+// Broadcast wakes everything waiting on c.
+func (c *SharedCond) Broadcast() {
+	c.seq.Add(1)
+	futexWakeAll(&c.seq)
+}
+
+// SharedBarrier makes a fixed number of participants, in any processes, wait
+// for each other. It is reusable: once all have arrived it resets for the next
+// round. Init it once before use.
+type SharedBarrier struct {
+	count   uint32
+	arrived atomic.Uint32
+	gen     atomic.Uint32 // bumped as each round completes
+	_       uint32
+}
+
+// Init prepares b for n participants. It must not be called while b is in use.
+func (b *SharedBarrier) Init(n int) error {
+	if n < 1 || n > math.MaxInt32 {
+		return EINVAL
+	}
+	b.count = uint32(n)
+	b.arrived.Store(0)
+	return nil
+}
+
+// Wait blocks until all participants have called Wait. Exactly one of them,
+// the last to arrive, gets serial == true, like PTHREAD_BARRIER_SERIAL_THREAD.
+// It fails with EINVAL if b was not initialized.
+func (b *SharedBarrier) Wait() (serial bool, err error) {
+	if b.count == 0 {
+		return false, EINVAL
+	}
+	gen := b.gen.Load()
+	if b.arrived.Add(1) == b.count {
+		b.arrived.Store(0)
+		b.gen.Add(1)
+		futexWakeAll(&b.gen)
+		return true, nil
+	}
+	for b.gen.Load() == gen {
+		_ = FutexWait(futexWord(&b.gen), gen, -1)
+	}
+	return false, nil
+}
+
+// rwWriter marks SharedRWMutex.state as held by a writer; below it is the
+// number of readers.
+const rwWriter = 1 << 31
+
+// SharedRWMutex is a reader/writer lock in shared memory. It prefers writers:
+// once a writer is waiting, new readers wait behind it, so a steady stream of
+// readers cannot starve writers. Its zero value is an unlocked mutex.
+type SharedRWMutex struct {
+	state   atomic.Uint32 // rwWriter | reader count
+	writers atomic.Uint32 // writers holding or waiting for the lock
+	wseq    atomic.Uint32 // futex writers wait on
+	rseq    atomic.Uint32 // futex readers wait on
+}
+
+// RLock locks rw for reading.
+func (rw *SharedRWMutex) RLock() {
+	for {
+		seq := rw.rseq.Load()
+		if rw.writers.Load() == 0 {
+			s := rw.state.Load()
+			if s&rwWriter == 0 {
+				if rw.state.CompareAndSwap(s, s+1) {
+					return
+				}
+				continue
+			}
+		}
+		_ = FutexWait(futexWord(&rw.rseq), seq, -1)
+	}
+}
+
+// RUnlock undoes one RLock.
+func (rw *SharedRWMutex) RUnlock() {
+	if rw.state.Add(^uint32(0)) == 0 && rw.writers.Load() != 0 {
+		rw.wseq.Add(1)
+		_, _ = FutexWake(futexWord(&rw.wseq), 1)
+	}
+}
+
+// Lock locks rw for writing, waiting for readers and other writers to leave.
+func (rw *SharedRWMutex) Lock() {
+	rw.writers.Add(1)
+	for {
+		seq := rw.wseq.Load()
+		if rw.state.CompareAndSwap(0, rwWriter) {
+			return
+		}
+		_ = FutexWait(futexWord(&rw.wseq), seq, -1)
+	}
+}
+
+// Unlock unlocks rw for writing. The next waiting writer goes first; readers
+// are let in once no writer is left.
+func (rw *SharedRWMutex) Unlock() {
+	rw.state.Store(0)
+	if rw.writers.Add(^uint32(0)) != 0 {
+		rw.wseq.Add(1)
+		_, _ = FutexWake(futexWord(&rw.wseq), 1)
+		return
+	}
+	rw.rseq.Add(1)
+	futexWakeAll(&rw.rseq)
+}
@@ -0,0 +1,190 @@
+package posix_test
+
+import (
+	"errors"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gopkg.in/ro-ag/posix.v1"
+)
+
+// TestSharedSyncExample builds and runs example/sharedsync, in which worker
+// processes meet at a SharedBarrier, share a counter under a SharedRWMutex and
+// report back through a SharedMutex and SharedCond.
+func TestSharedSyncExample(t *testing.T) {
+	bin := filepath.Join(t.TempDir(), "sharedsync")
+	build := exec.Command("go", "build", "-o", bin, "./example/sharedsync")
+	if out, err := build.CombinedOutput(); err != nil {
+		t.Fatalf("build example: %v\n%s", err, out)
+	}
+	out, err := exec.Command(bin).CombinedOutput()
+	if err != nil {
+		t.Fatalf("run example: %v\n%s", err, out)
+	}
+	if !strings.Contains(string(out), "sync OK") {
+		t.Fatalf("missing success marker; output:\n%s", out)
+	}
+	t.Logf("example output:\n%s", out)
+}
+
+type syncState struct {
+	Mu      posix.SharedMutex
+	Cond    posix.SharedCond
+	Barrier posix.SharedBarrier
+	RW      posix.SharedRWMutex
+	// Data guarded by the primitives lives in the mapping too, where the race
+	// detector, which cannot see futex-based synchronization, does not look.
+	Ready   bool
+	Counter uint64
+}
+
+func newSyncState(t *testing.T) *syncState {
+	t.Helper()
+	s, err := posix.View[syncState](mapAnon(t, posix.Getpagesize()), 0)
+	if err != nil {
+		t.Fatalf("View: %v", err)
+	}
+	return s
+}
+
+// TestSharedCond: Wait times out with the mutex held again, and Signal wakes a
+// waiter.
+func TestSharedCond(t *testing.T) {
+	s := newSyncState(t)
+	if err := s.Mu.Lock(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Cond.Wait(&s.Mu, 10*time.Millisecond); !errors.Is(err, posix.ETIMEDOUT) {
+		t.Errorf("Wait with nobody signalling = %v, want ETIMEDOUT", err)
+	}
+	if err := s.Mu.TryLock(); !errors.Is(err, posix.EBUSY) {
+		t.Errorf("mutex after a timed-out Wait: TryLock = %v, want EBUSY", err)
+	}
+
+	go func() {
+		_ = s.Mu.Lock()
+		s.Ready = true
+		s.Cond.Signal()
+		_ = s.Mu.Unlock()
+	}()
+	deadline := time.Now().Add(5 * time.Second)
+	for !s.Ready && time.Now().Before(deadline) {
+		if err := s.Cond.Wait(&s.Mu, time.Second); err != nil && !errors.Is(err, posix.ETIMEDOUT) {
+			t.Fatalf("Wait: %v", err)
+		}
+	}
+	if !s.Ready {
+		t.Error("condition never signalled")
+	}
+	_ = s.Mu.Unlock()
+}
+
+// TestSharedBarrier: rounds of participants pass together, with one serial
+// participant per round.
+func TestSharedBarrier(t *testing.T) {
+	s := newSyncState(t)
+	if _, err := s.Barrier.Wait(); !errors.Is(err, posix.EINVAL) {
+		t.Errorf("Wait on an uninitialized barrier = %v, want EINVAL", err)
+	}
+	const n, rounds = 4, 50
+	if err := s.Barrier.Init(n); err != nil {
+		t.Fatal(err)
+	}
+	var serials, passed atomic.Int32
+	var wg sync.WaitGroup
+	for range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for r := range rounds {
+				passed.Add(1)
+				serial, err := s.Barrier.Wait()
+				if err != nil {
+					t.Errorf("Wait: %v", err)
+					return
+				}
+				if serial {
+					serials.Add(1)
+				}
+				if got := passed.Load(); got < int32((r+1)*n) {
+					t.Errorf("round %d: passed the barrier with only %d arrivals", r, got)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if serials.Load() != rounds {
+		t.Errorf("%d serial participants over %d rounds, want one per round", serials.Load(), rounds)
+	}
+}
+
+// TestSharedRWMutex: readers share the lock, writers exclude everyone, and a
+// waiting writer holds off new readers.
+func TestSharedRWMutex(t *testing.T) {
+	s := newSyncState(t)
+	s.RW.RLock()
+	s.RW.RLock() // readers share
+
+	locked := make(chan struct{})
+	go func() {
+		s.RW.Lock()
+		close(locked)
+	}()
+	time.Sleep(20 * time.Millisecond) // let the writer queue up
+	rlocked := make(chan struct{})
+	go func() {
+		s.RW.RLock()
+		close(rlocked)
+	}()
+	time.Sleep(20 * time.Millisecond)
+	select {
+	case <-locked:
+		t.Fatal("writer got the lock while readers held it")
+	case <-rlocked:
+		t.Fatal("new reader got in ahead of a waiting writer")
+	default:
+	}
+	s.RW.RUnlock()
+	s.RW.RUnlock()
+	select {
+	case <-locked:
+	case <-time.After(5 * time.Second):
+		t.Fatal("writer not woken when the readers left")
+	}
+	s.RW.Unlock()
+	select {
+	case <-rlocked:
+	case <-time.After(5 * time.Second):
+		t.Fatal("reader not woken when the writer left")
+	}
+	s.RW.RUnlock()
+
+	var wg sync.WaitGroup
+	for g := range 6 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 1000 {
+				if g%3 == 0 {
+					s.RW.RLock()
+					_ = s.Counter
+					s.RW.RUnlock()
+					continue
+				}
+				s.RW.Lock()
+				s.Counter++
+				s.RW.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if s.Counter != 4*1000 {
+		t.Errorf("counter = %d, want %d", s.Counter, 4*1000)
+	}
+}
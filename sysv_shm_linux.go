@@ -0,0 +1,144 @@
+package posix
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+//goland:noinspection GoSnakeCaseUsage
+const (
+	IPC_PRIVATE = 0 // Shmget key for a new segment no other key can reach
+
+	IPC_CREAT  = 0o1000 // create the segment if it does not exist
+	IPC_EXCL   = 0o2000 // with IPC_CREAT, fail if the segment exists
+	IPC_NOWAIT = 0o4000
+
+	IPC_RMID = 0 // Shmctl: remove the segment once the last process detaches
+	IPC_SET  = 1 // Shmctl: set the owner and mode from the ShmidDs
+	IPC_STAT = 2 // Shmctl: fill in the ShmidDs
+	IPC_INFO = 3
+
+	SHM_LOCK   = 11 // Shmctl: keep the segment in memory
+	SHM_UNLOCK = 12 // Shmctl: let the segment be swapped again
+	SHM_STAT   = 13
+	SHM_INFO   = 14
+
+	SHM_HUGETLB   = 0o4000  // Shmget: back the segment with huge pages
+	SHM_NORESERVE = 0o10000 // Shmget: do not reserve swap for the segment
+
+	SHM_RDONLY = 0o10000  // Shmat: attach read-only
+	SHM_RND    = 0o20000  // Shmat: round addr down to a multiple of SHMLBA
+	SHM_REMAP  = 0o40000  // Shmat: replace whatever is mapped at addr
+	SHM_EXEC   = 0o100000 // Shmat: allow the segment to be executed
+
+	SHM_DEST   = 0o1000 // IpcPerm.Mode: removed once the last process detaches
+	SHM_LOCKED = 0o2000 // IpcPerm.Mode: locked by SHM_LOCK
+)
+
+// IpcPerm is struct ipc64_perm: the owner and permissions of a System V IPC
+// object.
+type IpcPerm struct {
+	Key  int32
+	Uid  uint32
+	Gid  uint32
+	Cuid uint32
+	Cgid uint32
+	Mode uint32
+	Seq  uint16
+	_    uint16
+	_    [5]uint32
+}
+
+// ShmidDs is struct shmid64_ds: the state of a System V shared-memory
+// segment, as reported by Shmctl with IPC_STAT.
+type ShmidDs struct {
+	Perm   IpcPerm
+	Segsz  uint64 // size in bytes
+	Atime  int64  // last Shmat
+	Dtime  int64  // last Shmdt
+	Ctime  int64  // last change
+	Cpid   int32  // creator
+	Lpid   int32  // last Shmat or Shmdt
+	Nattch uint64 // current number of attaches
+	_      [2]uint64
+}
+
+// Shmget returns the identifier of the System V shared-memory segment for
+// key, creating a segment of size bytes if flags holds IPC_CREAT. The low 9
+// bits of flags are the permissions of a new segment.
+func Shmget(key int, size int, flags int) (int, error) {
+	if size < 0 {
+		return -1, EINVAL
+	}
+	return shmget(key, uintptr(size), flags)
+}
+
+// Shmat attaches the segment id, at address if it is not nil, and returns its
+// memory. As with Mmap, the slice is tracked so that Shmdt can recover the
+// attach from it; Munmap also works on it, although Shmdt is the usual way.
+func Shmat(id int, address unsafe.Pointer, flags int) ([]byte, error) {
+	var ds ShmidDs
+	if _, err := shmctl(id, IPC_STAT, &ds); err != nil {
+		return nil, err
+	}
+	prot := PROT_READ
+	if flags&SHM_RDONLY == 0 {
+		prot |= PROT_WRITE
+	}
+	if flags&SHM_EXEC != 0 {
+		prot |= PROT_EXEC
+	}
+	return mapper.shmat(id, uintptr(address), flags, uintptr(ds.Segsz), prot)
+}
+
+// shmat attaches the segment and registers the attach like an Mmap; under
+// SHM_REMAP, the entries it replaced are dropped, and an attach inside a
+// Reservation becomes one of its commits.
+func (m *mmapper) shmat(id int, address uintptr, flags int, length uintptr, prot int) ([]byte, error) {
+	if length == 0 {
+		return nil, EINVAL
+	}
+	m.Lock()
+	defer m.Unlock()
+	addr, err := shmat(id, address, flags)
+	if err != nil {
+		return nil, err
+	}
+	b := unsafe.Slice((*byte)(unsafe.Pointer(addr)), length)
+	mp := mapping{data: b, fd: -1, prot: prot}
+	if flags&SHM_REMAP != 0 {
+		m.forget(addr, length)
+		m.place(mp)
+		return b, nil
+	}
+	m.active[&b[0]] = mp
+	return b, nil
+}
+
+// Shmdt detaches a segment attached with Shmat. The segment itself lives on
+// until it is removed with IPC_RMID and every process has detached.
+func Shmdt(b []byte) error {
+	return mapper.release(b, func(addr uintptr, _ uintptr) error {
+		return shmdt(addr)
+	})
+}
+
+// Shmctl performs cmd on the segment id: IPC_STAT fills in buf, IPC_SET
+// applies its Perm.Uid, Perm.Gid and Perm.Mode, IPC_RMID removes the segment,
+// and SHM_LOCK and SHM_UNLOCK pin it in memory and release it. buf is only
+// used by the commands that need it.
+func Shmctl(id int, cmd int, buf *ShmidDs) (int, error) {
+	return shmctl(id, cmd, buf)
+}
+
+// Ftok derives a System V IPC key from an existing file and a project id, the
+// way glibc's ftok does: the low 16 bits of the inode number, the low 8 bits
+// of the device number and the low 8 bits of id. Programs that agree on the
+// file and id agree on the key.
+func Ftok(path string, id int) (int, error) {
+	var st syscall.Stat_t
+	if err := syscall.Stat(path, &st); err != nil {
+		return -1, err
+	}
+	return int(int32(uint32(st.Ino&0xffff) | uint32(st.Dev&0xff)<<16 | uint32(id&0xff)<<24)), nil
+}
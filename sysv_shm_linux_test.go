@@ -0,0 +1,179 @@
+package posix_test
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"unsafe"
+
+	"gopkg.in/ro-ag/posix.v1"
+)
+
+func TestShmSysV(t *testing.T) {
+	id, err := posix.Shmget(posix.IPC_PRIVATE, 10000, posix.IPC_CREAT|0o600)
+	if err != nil {
+		t.Fatalf("Shmget: %v", err)
+	}
+	defer func() { _, _ = posix.Shmctl(id, posix.IPC_RMID, nil) }()
+
+	// Attach once where the kernel likes, and once at an address of our own.
+	a, err := posix.Shmat(id, nil, 0)
+	if err != nil {
+		t.Fatalf("Shmat: %v", err)
+	}
+	const addr = 0x36000000000
+	b, err := posix.Shmat(id, unsafe.Pointer(uintptr(addr)), 0)
+	if err != nil {
+		t.Fatalf("Shmat at %#x: %v", addr, err)
+	}
+	if got := uintptr(unsafe.Pointer(&b[0])); got != addr {
+		t.Errorf("Shmat attached at %#x, want %#x", got, addr)
+	}
+	if len(a) != 10000 || len(b) != 10000 {
+		t.Errorf("attaches are %d and %d bytes, want the segment's 10000", len(a), len(b))
+	}
+	copy(a[9990:], "sysv")
+	if string(b[9990:9994]) != "sysv" {
+		t.Errorf("second attach reads %q, want the first attach's write", b[9990:9994])
+	}
+
+	var ds posix.ShmidDs
+	if _, err := posix.Shmctl(id, posix.IPC_STAT, &ds); err != nil {
+		t.Fatalf("Shmctl IPC_STAT: %v", err)
+	}
+	if ds.Segsz != 10000 || ds.Nattch != 2 || ds.Cpid != int32(os.Getpid()) || ds.Perm.Mode&0o777 != 0o600 {
+		t.Errorf("IPC_STAT = %+v, want 10000 bytes, 2 attaches, created by us with mode 0600", ds)
+	}
+	if _, err := posix.Shmctl(id, posix.SHM_LOCK, nil); err == nil {
+		if _, err := posix.Shmctl(id, posix.IPC_STAT, &ds); err != nil || ds.Perm.Mode&posix.SHM_LOCKED == 0 {
+			t.Errorf("IPC_STAT after SHM_LOCK = %v, mode %#o, want SHM_LOCKED", err, ds.Perm.Mode)
+		}
+		if _, err := posix.Shmctl(id, posix.SHM_UNLOCK, nil); err != nil {
+			t.Errorf("Shmctl SHM_UNLOCK: %v", err)
+		}
+	} else if !errors.Is(err, posix.EPERM) {
+		t.Errorf("Shmctl SHM_LOCK: %v", err)
+	}
+
+	if err := posix.Shmdt(b[:100]); !errors.Is(err, posix.EINVAL) {
+		t.Errorf("Shmdt of part of an attach = %v, want EINVAL", err)
+	}
+	if err := posix.Shmdt(b); err != nil {
+		t.Fatalf("Shmdt: %v", err)
+	}
+	if err := posix.Shmdt(b); !errors.Is(err, posix.EINVAL) {
+		t.Errorf("second Shmdt = %v, want EINVAL", err)
+	}
+	if _, err := posix.Shmctl(id, posix.IPC_RMID, nil); err != nil {
+		t.Fatalf("Shmctl IPC_RMID: %v", err)
+	}
+	// Removed, the segment lives on while a is attached.
+	if string(a[9990:9994]) != "sysv" {
+		t.Errorf("attach after IPC_RMID reads %q", a[9990:9994])
+	}
+	if err := posix.Shmdt(a); err != nil {
+		t.Fatalf("Shmdt: %v", err)
+	}
+	if _, err := posix.Shmctl(id, posix.IPC_STAT, &ds); err == nil {
+		t.Error("segment still exists after IPC_RMID and the last Shmdt")
+	}
+}
+
+// TestShmatRemapIntoReservation: an attach laid over a Reservation with
+// SHM_REMAP becomes one of its commits, so Shmdt refuses it and Release takes
+// it down with the rest of the range.
+func TestShmatRemapIntoReservation(t *testing.T) {
+	pg := posix.Getpagesize()
+	id, err := posix.Shmget(posix.IPC_PRIVATE, pg, posix.IPC_CREAT|0o600)
+	if err != nil {
+		t.Fatalf("Shmget: %v", err)
+	}
+	defer func() { _, _ = posix.Shmctl(id, posix.IPC_RMID, nil) }()
+	r, err := posix.Reserve(nil, 4*pg)
+	if err != nil {
+		t.Fatalf("Reserve: %v", err)
+	}
+	b, err := posix.Shmat(id, unsafe.Pointer(r.Addr()+uintptr(pg)), posix.SHM_REMAP)
+	if err != nil {
+		_ = r.Release()
+		t.Fatalf("Shmat with SHM_REMAP: %v", err)
+	}
+	copy(b, "sysv")
+	if err := posix.Shmdt(b); !errors.Is(err, posix.EINVAL) {
+		t.Errorf("Shmdt of an attach inside the reservation = %v, want EINVAL", err)
+	}
+	if err := r.Release(); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	var ds posix.ShmidDs
+	if _, err := posix.Shmctl(id, posix.IPC_STAT, &ds); err != nil || ds.Nattch != 0 {
+		t.Errorf("IPC_STAT after Release = %v, %d attaches, want none", err, ds.Nattch)
+	}
+}
+
+// TestShmgetKey: a key made by Ftok reaches the same segment, and IPC_EXCL
+// refuses to create it twice.
+func TestShmgetKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "key")
+	if err := os.WriteFile(path, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	key, err := posix.Ftok(path, 'P')
+	if err != nil {
+		t.Fatalf("Ftok: %v", err)
+	}
+	if other, _ := posix.Ftok(path, 'Q'); other == key {
+		t.Errorf("Ftok gave %#x for two project ids", key)
+	}
+	if _, err := posix.Ftok(filepath.Join(t.TempDir(), "missing"), 'P'); err == nil {
+		t.Error("Ftok of a missing file succeeded")
+	}
+
+	id, err := posix.Shmget(key, 4096, posix.IPC_CREAT|posix.IPC_EXCL|0o600)
+	if err != nil {
+		t.Fatalf("Shmget: %v", err)
+	}
+	defer func() { _, _ = posix.Shmctl(id, posix.IPC_RMID, nil) }()
+	if _, err := posix.Shmget(key, 4096, posix.IPC_CREAT|posix.IPC_EXCL|0o600); !errors.Is(err, posix.EEXIST) {
+		t.Errorf("second exclusive Shmget = %v, want EEXIST", err)
+	}
+	if again, err := posix.Shmget(key, 0, 0); err != nil || again != id {
+		t.Errorf("Shmget of the existing key = %d, %v, want %d", again, err, id)
+	}
+
+	// glibc's ftok agrees on the key.
+	cc, err := exec.LookPath("cc")
+	if err != nil {
+		return
+	}
+	dir := t.TempDir()
+	src := filepath.Join(dir, "ftok.c")
+	if err := os.WriteFile(src, []byte(ftokC), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	prog := filepath.Join(dir, "ftok")
+	if out, err := exec.Command(cc, "-o", prog, src).CombinedOutput(); err != nil {
+		t.Logf("cannot build the C program: %v\n%s", err, out)
+		return
+	}
+	out, err := exec.Command(prog, path).Output()
+	if err != nil {
+		t.Fatalf("C program: %v", err)
+	}
+	if c, _ := strconv.Atoi(strings.TrimSpace(string(out))); c != key {
+		t.Errorf("Ftok = %#x, glibc's ftok = %#x", key, c)
+	}
+}
+
+const ftokC = `#include <stdio.h>
+#include <sys/ipc.h>
+
+int main(int argc, char **argv) {
+	printf("%d\n", ftok(argv[1], 'P'));
+	return 0;
+}
+`
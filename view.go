@@ -0,0 +1,105 @@
+//go:build darwin || linux
+
+package posix
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"unsafe"
+)
+
+var (
+	// ErrRange is returned when a typed view does not fit inside the region.
+	ErrRange = errors.New("posix: view out of range")
+	// ErrAlignment is returned when a typed view would be misaligned for its
+	// type.
+	ErrAlignment = errors.New("posix: view misaligned")
+	// ErrNotShareable is returned for a type that holds Go pointers, strings,
+	// slices, maps, interfaces, channels or funcs — values that mean nothing in
+	// another process's address space.
+	ErrNotShareable = errors.New("posix: type is not shareable")
+)
+
+// View returns a *T laid over m at byte offset off, after checking that T is
+// shareable (see ErrNotShareable), that the value fits inside m, and that it is
+// aligned for T. The pointer is valid only while m stays mapped.
+func View[T any](m []byte, off int) (*T, error) {
+	p, err := view[T](m, off, 1)
+	if err != nil {
+		return nil, err
+	}
+	return (*T)(p), nil
+}
+
+// SliceOf returns n consecutive T values laid over m starting at byte offset
+// off, with the same checks as View. The slice is valid only while m stays
+// mapped.
+func SliceOf[T any](m []byte, off, n int) ([]T, error) {
+	p, err := view[T](m, off, n)
+	if err != nil || n == 0 {
+		return nil, err
+	}
+	return unsafe.Slice((*T)(p), n), nil
+}
+
+func view[T any](m []byte, off, n int) (unsafe.Pointer, error) {
+	var zero T
+	if err := shareable(reflect.TypeOf(&zero).Elem()); err != nil {
+		return nil, err
+	}
+	size, align := unsafe.Sizeof(zero), unsafe.Alignof(zero)
+	if off < 0 || n < 0 || off > len(m) {
+		return nil, ErrRange
+	}
+	if size != 0 && uintptr(n) > uintptr(len(m)-off)/size {
+		return nil, ErrRange
+	}
+	if off == len(m) {
+		// An empty view at the end: there is no byte of m to point at.
+		return unsafe.Pointer(&zero), nil
+	}
+	p := unsafe.Pointer(&m[off])
+	if uintptr(p)%align != 0 {
+		return nil, ErrAlignment
+	}
+	return p, nil
+}
+
+// shareableCache memoizes the shareable check, so the reflection walk runs once
+// per type.
+var shareableCache sync.Map // reflect.Type -> error
+
+// shareable reports, with a descriptive ErrNotShareable, whether t holds
+// anything that only makes sense inside one address space.
+func shareable(t reflect.Type) error {
+	if err, ok := shareableCache.Load(t); ok {
+		if err == nil {
+			return nil
+		}
+		return err.(error)
+	}
+	err := shareableWalk(t, t.String())
+	shareableCache.Store(t, err)
+	return err
+}
+
+func shareableWalk(t reflect.Type, path string) error {
+	switch t.Kind() {
+	case reflect.Array:
+		return shareableWalk(t.Elem(), path+"[]")
+	case reflect.Struct:
+		for i := range t.NumField() {
+			f := t.Field(i)
+			if err := shareableWalk(f.Type, path+"."+f.Name); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Pointer, reflect.UnsafePointer, reflect.String, reflect.Slice,
+		reflect.Map, reflect.Interface, reflect.Chan, reflect.Func:
+		return fmt.Errorf("%w: %s is a %s", ErrNotShareable, path, t.Kind())
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+//go:build darwin || linux
+
+package posix_test
+
+import (
+	"errors"
+	"testing"
+
+	"gopkg.in/ro-ag/posix.v1"
+)
+
+type viewHeader struct {
+	Magic uint64
+	Count uint32
+	Flags [4]byte
+}
+
+// TestViewBoundsAndAlignment: View and SliceOf hand out typed pointers only when
+// the value fits and is aligned, and writes through them land in the mapping.
+func TestViewBoundsAndAlignment(t *testing.T) {
+	pg := posix.Getpagesize()
+	buf, _, err := posix.Mmap(nil, pg, posix.PROT_RDWR, posix.MAP_ANON|posix.MAP_PRIVATE, -1, 0)
+	if err != nil {
+		t.Fatalf("Mmap: %v", err)
+	}
+	defer func() { _ = posix.Munmap(buf) }()
+
+	h, err := posix.View[viewHeader](buf, 0)
+	if err != nil {
+		t.Fatalf("View: %v", err)
+	}
+	h.Magic = 0x0102030405060708
+	if buf[0] != 0x08 {
+		t.Errorf("write through View not visible in the mapping: buf[0] = %#x", buf[0])
+	}
+
+	words, err := posix.SliceOf[uint64](buf, 16, 4)
+	if err != nil {
+		t.Fatalf("SliceOf: %v", err)
+	}
+	words[3] = 7
+	if buf[16+24] != 7 {
+		t.Errorf("write through SliceOf not visible in the mapping")
+	}
+	if s, err := posix.SliceOf[uint64](buf, 0, 0); err != nil || len(s) != 0 {
+		t.Errorf("SliceOf(n=0) = %v, %v; want empty, nil", s, err)
+	}
+	if s, err := posix.SliceOf[uint64](buf, pg, 0); err != nil || len(s) != 0 {
+		t.Errorf("SliceOf(off=len, n=0) = %v, %v; want empty, nil", s, err)
+	}
+	if v, err := posix.View[struct{}](buf, pg); err != nil || v == nil {
+		t.Errorf("View of an empty type at the end = %v, %v; want a pointer, nil", v, err)
+	}
+
+	for _, c := range []struct {
+		name string
+		err  error
+		want error
+	}{
+		{"View past the end", errOf(posix.View[viewHeader](buf, pg-8)), posix.ErrRange},
+		{"View at negative offset", errOf(posix.View[uint64](buf, -8)), posix.ErrRange},
+		{"View misaligned", errOf(posix.View[uint64](buf, 4)), posix.ErrAlignment},
+		{"SliceOf past the end", errOf(posix.SliceOf[uint64](buf, 0, pg/8+1)), posix.ErrRange},
+		{"SliceOf past the end, empty", errOf(posix.SliceOf[uint64](buf, pg+1, 0)), posix.ErrRange},
+		{"SliceOf negative count", errOf(posix.SliceOf[uint64](buf, 0, -1)), posix.ErrRange},
+		{"SliceOf misaligned", errOf(posix.SliceOf[uint32](buf, 2, 1)), posix.ErrAlignment},
+	} {
+		if !errors.Is(c.err, c.want) {
+			t.Errorf("%s = %v, want %v", c.name, c.err, c.want)
+		}
+	}
+}
+
+// TestViewRejectsPointerTypes: any type holding Go pointers — directly, nested
+// in a struct, or inside an array — is refused.
+func TestViewRejectsPointerTypes(t *testing.T) {
+	buf := make([]byte, 256)
+	type nested struct {
+		N    int64
+		Name string
+	}
+	for _, c := range []struct {
+		name string
+		err  error
+	}{
+		{"*int", errOf(posix.View[*int](buf, 0))},
+		{"string", errOf(posix.View[string](buf, 0))},
+		{"[]byte", errOf(posix.View[[]byte](buf, 0))},
+		{"map", errOf(posix.View[map[int]int](buf, 0))},
+		{"interface", errOf(posix.View[any](buf, 0))},
+		{"chan", errOf(posix.View[chan int](buf, 0))},
+		{"func", errOf(posix.View[func()](buf, 0))},
+		{"nested string", errOf(posix.View[nested](buf, 0))},
+		{"array of nested", errOf(posix.SliceOf[[2]nested](buf, 0, 1))},
+	} {
+		if !errors.Is(c.err, posix.ErrNotShareable) {
+			t.Errorf("%s: err = %v, want ErrNotShareable", c.name, c.err)
+		}
+	}
+	if _, err := posix.View[[4]viewHeader](buf, 0); err != nil {
+		t.Errorf("array of plain structs: %v", err)
+	}
+}
+
+func errOf[T any](_ T, err error) error { return err }